@@ -2,36 +2,52 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bhanurp/jfvm/cmd/descriptions"
 	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal"
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/sync/errgroup"
 )
 
+// BenchmarkResult holds one version's timed runs. Failed runs (unexpected
+// exit code or timeout) are counted in Failed and left out of every timing
+// but TotalTime. AverageTime, MedianTime, StdDev and the confidence interval
+// also leave out outliers.
 type BenchmarkResult struct {
 	Version     string
 	Iterations  int
+	Warmup      int
 	TotalTime   time.Duration
 	AverageTime time.Duration
+	MedianTime  time.Duration
+	StdDev      time.Duration
+	CILow       time.Duration
+	CIHigh      time.Duration
 	MinTime     time.Duration
 	MaxTime     time.Duration
+	Outliers    int
+	Failed      int
 	SuccessRate float64
 	Executions  []ExecutionResult
+	Samples     []float64 // successful durations in nanoseconds, outliers removed
+	Comparison  *BenchmarkComparison
 }
 
 var Benchmark = &cli.Command{
 	Name:        "benchmark",
 	Usage:       descriptions.Benchmark.Usage,
-	ArgsUsage:   "<version1,version2,...> -- <jf-command> [args...]",
+	ArgsUsage:   "<version1,version2,...> -- <jf-command> [args...] | --scenario <file> <versions>",
 	Description: descriptions.Benchmark.Format(),
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.IntFlag{
 			Name:  "iterations",
 			Usage: "Number of iterations per version",
@@ -57,8 +73,18 @@ var Benchmark = &cli.Command{
 			Usage: "Output format: table, json, csv",
 			Value: "table",
 		},
-	},
+	}, append(append(benchmarkStatsFlags(), scenarioFlags()...), isolateFlags()...)...),
 	Action: func(c *cli.Context) error {
+		// Extract configuration
+		config, err := extractBenchmarkConfig(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		if path := c.String("scenario"); path != "" {
+			return runBenchmarkScenario(c, path, config)
+		}
+
 		// Parse and validate arguments
 		versions, jfCommand, err := parseArguments(c.Args().Slice())
 		if err != nil {
@@ -71,17 +97,17 @@ var Benchmark = &cli.Command{
 			return err
 		}
 
-		// Extract configuration
-		config := extractBenchmarkConfig(c)
+		sb, err := newSandboxes(config.Isolate, config.IsolateWorkDir, resolvedVersions)
+		if err != nil {
+			return err
+		}
+		defer sb.Remove()
 
 		// Run benchmarks
-		results, err := runBenchmarks(resolvedVersions, jfCommand, config)
-		if err != nil && config.Format == "table" {
-			fmt.Fprintf(os.Stderr, "⚠️  Warning: %v\n\n", err)
-		}
+		results := runBenchmarks(resolvedVersions, jfCommand, config, sb)
 
 		// Display results
-		displayBenchmarkResults(results, config.Format, config.NoColor, config.Detailed)
+		displayBenchmarkResults(results, config)
 
 		return nil
 	},
@@ -89,10 +115,16 @@ var Benchmark = &cli.Command{
 
 type BenchmarkConfig struct {
 	Iterations int
+	Warmup     int
 	Timeout    time.Duration
 	Format     string
 	NoColor    bool
 	Detailed   bool
+
+	Isolate        bool
+	IsolateWorkDir bool
+
+	Stats benchmarkStats
 }
 
 func parseArguments(args []string) (versions []string, jfCommand []string, err error) {
@@ -129,12 +161,13 @@ func parseArguments(args []string) (versions []string, jfCommand []string, err e
 }
 
 func validateVersions(versions []string) ([]string, error) {
+	resolver := internal.Resolver{InstalledOnly: true}
 	resolvedVersions := make([]string, len(versions))
 	for i, version := range versions {
 		version = strings.TrimSpace(version)
-		resolved, err := utils.ResolveVersionOrAlias(version)
+		resolved, err := resolver.Resolve(version)
 		if err != nil {
-			resolved = version
+			return nil, fmt.Errorf("version %s not found: %w", version, err)
 		}
 		if err := utils.CheckVersionExists(resolved); err != nil {
 			return nil, fmt.Errorf("version %s (%s) not found: %w", version, resolved, err)
@@ -144,82 +177,140 @@ func validateVersions(versions []string) ([]string, error) {
 	return resolvedVersions, nil
 }
 
-func extractBenchmarkConfig(c *cli.Context) BenchmarkConfig {
+func extractBenchmarkConfig(c *cli.Context) (BenchmarkConfig, error) {
+	benchStats, err := benchmarkStatsFrom(c)
+	if err != nil {
+		return BenchmarkConfig{}, err
+	}
+	if c.Int("iterations") < 1 {
+		return BenchmarkConfig{}, fmt.Errorf("--iterations must be at least 1")
+	}
+
 	return BenchmarkConfig{
 		Iterations: c.Int("iterations"),
+		Warmup:     c.Int("warmup"),
 		Timeout:    time.Duration(c.Int("timeout")) * time.Second,
 		Format:     c.String("format"),
 		NoColor:    c.Bool("no-color"),
 		Detailed:   c.Bool("detailed"),
-	}
+
+		Isolate:        c.Bool("isolate"),
+		IsolateWorkDir: c.Bool("isolate-workdir"),
+
+		Stats: benchStats,
+	}, nil
 }
 
-func runBenchmarks(versions []string, jfCommand []string, config BenchmarkConfig) ([]BenchmarkResult, error) {
+func runBenchmarks(versions []string, jfCommand []string, config BenchmarkConfig, sb *sandboxes) []BenchmarkResult {
 	// Only show headers for table format
 	if config.Format == "table" {
 		fmt.Printf("🏁 Benchmarking JFrog CLI versions: %s\n", strings.Join(versions, ", "))
 		fmt.Printf("📝 Command: jf %s\n", strings.Join(jfCommand, " "))
-		fmt.Printf("🔄 Iterations: %d per version\n\n", config.Iterations)
+		fmt.Printf("🔄 Iterations: %d per version", config.Iterations)
+		if config.Warmup > 0 {
+			fmt.Printf(" after %d warmup", config.Warmup)
+		}
+		fmt.Println(", interleaved")
+		if sb != nil {
+			fmt.Println(sb.describe())
+		}
+		fmt.Println()
 	}
 
-	// Run benchmarks
-	results := make([]BenchmarkResult, len(versions))
-	g, ctx := errgroup.WithContext(context.Background())
-
-	for i, version := range versions {
-		i, version := i, version
-		g.Go(func() error {
-			result, err := runBenchmark(ctx, version, jfCommand, config.Iterations, config.Timeout)
-			results[i] = result
-			return err
-		})
+	tasks := make([]benchmarkTask, len(versions))
+	for i := range versions {
+		tasks[i] = benchmarkTask{
+			Command:    jfCommand,
+			Iterations: config.Iterations,
+			Warmup:     config.Warmup,
+			Timeout:    config.Timeout,
+			Exec:       sb.exec(i, execOptions{}),
+			Stats:      config.Stats,
+		}
 	}
 
-	return results, g.Wait()
+	results := runBenchmarkRounds(versions, tasks)
+	config.Stats.compareWithFastest(results)
+	return results
 }
 
-func runBenchmark(ctx context.Context, version string, jfCommand []string, iterations int, timeout time.Duration) (BenchmarkResult, error) {
-	result := BenchmarkResult{
-		Version:    version,
-		Iterations: iterations,
-		MinTime:    time.Hour,
-		Executions: make([]ExecutionResult, iterations),
-	}
-
-	successCount := 0
-
-	for i := 0; i < iterations; i++ {
-		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
-		exec, err := executeJFCommand(timeoutCtx, version, jfCommand)
-		cancel()
-
-		result.Executions[i] = exec
-		result.TotalTime += exec.Duration
+// benchmarkTask is one command to benchmark on one version. Runs that
+// exit with ExpectExit count as successful; warmup runs are not timed.
+type benchmarkTask struct {
+	Command    []string
+	Iterations int
+	Warmup     int
+	Timeout    time.Duration
+	Exec       execOptions
+	ExpectExit int
+	Stats      benchmarkStats
+}
 
-		if exec.ExitCode == 0 {
-			successCount++
+// runBenchmarkRounds times version i with tasks[i]. Versions never run at
+// the same time, so they don't compete for CPU, disk or network: each round
+// runs one iteration of every version in turn, and drift during the
+// benchmark (thermal throttling, caches, a busy server) affects them alike.
+// Warmup runs are interleaved the same way before the first timed round.
+func runBenchmarkRounds(versions []string, tasks []benchmarkTask) []BenchmarkResult {
+	results := make([]BenchmarkResult, len(versions))
+	durations := make([][]float64, len(versions))
+	warmupRounds, rounds := 0, 0
+	for i, version := range versions {
+		results[i] = BenchmarkResult{
+			Version:    version,
+			Iterations: tasks[i].Iterations,
+			Warmup:     tasks[i].Warmup,
+			Executions: make([]ExecutionResult, 0, tasks[i].Iterations),
 		}
+		warmupRounds = max(warmupRounds, tasks[i].Warmup)
+		rounds = max(rounds, tasks[i].Iterations)
+	}
 
-		if exec.Duration < result.MinTime {
-			result.MinTime = exec.Duration
-		}
-		if exec.Duration > result.MaxTime {
-			result.MaxTime = exec.Duration
+	for round := 0; round < warmupRounds; round++ {
+		for i, version := range versions {
+			if round < tasks[i].Warmup {
+				tasks[i].run(version)
+			}
 		}
+	}
 
-		if err != nil {
-			fmt.Printf("⚠️  Iteration %d for %s failed: %v\n", i+1, version, err)
+	for round := 0; round < rounds; round++ {
+		for i, version := range versions {
+			if round >= tasks[i].Iterations {
+				continue
+			}
+			exec := tasks[i].run(version)
+			results[i].Executions = append(results[i].Executions, exec)
+			results[i].TotalTime += exec.Duration
+
+			if exec.TimedOut || exec.ExitCode != tasks[i].ExpectExit {
+				// A failed run's time says nothing about the version's speed
+				results[i].Failed++
+				continue
+			}
+			durations[i] = append(durations[i], float64(exec.Duration))
 		}
 	}
 
-	result.AverageTime = result.TotalTime / time.Duration(iterations)
-	result.SuccessRate = float64(successCount) / float64(iterations) * 100
+	for i := range results {
+		tasks[i].Stats.summarize(&results[i], durations[i])
+		if results[i].Iterations > 0 {
+			results[i].SuccessRate = float64(len(durations[i])) / float64(results[i].Iterations) * 100
+		}
+	}
+	return results
+}
 
-	return result, nil
+// run executes the task's command once with version.
+func (task benchmarkTask) run(version string) ExecutionResult {
+	ctx, cancel := context.WithTimeout(context.Background(), task.Timeout)
+	defer cancel()
+	exec, _ := executeJFCommandWith(ctx, version, task.Command, task.Exec)
+	return exec
 }
 
-func displayBenchmarkResults(results []BenchmarkResult, format string, noColor, detailed bool) {
-	if noColor {
+func displayBenchmarkResults(results []BenchmarkResult, config BenchmarkConfig) {
+	if config.NoColor {
 		color.NoColor = true
 	}
 
@@ -230,42 +321,105 @@ func displayBenchmarkResults(results []BenchmarkResult, format string, noColor,
 		yellowColor = color.New(color.FgYellow, color.Bold)
 	)
 
-	switch format {
+	switch config.Format {
 	case "json":
 		displayBenchmarkJSON(results)
 	case "csv":
 		displayBenchmarkCSV(results)
 	default:
-		displayBenchmarkTable(results, greenColor, redColor, blueColor, yellowColor, detailed)
+		displayBenchmarkTable(results, config.Stats, greenColor, redColor, blueColor, yellowColor, config.Detailed)
 	}
 }
 
-func displayBenchmarkTable(results []BenchmarkResult, greenColor, redColor, blueColor, yellowColor *color.Color, detailed bool) {
+func displayBenchmarkTable(results []BenchmarkResult, benchStats benchmarkStats, greenColor, redColor, blueColor, yellowColor *color.Color, detailed bool) {
 	fmt.Printf("📊 BENCHMARK RESULTS\n")
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n\n")
 
+	displayBenchmarkRows(results, benchStats, greenColor, redColor, blueColor, yellowColor)
+
+	fmt.Printf("\n🏆 Performance Summary (%s, %s confidence):\n", benchStats.testName(), formatConfidence(benchStats.Confidence))
+	displayBenchmarkWinner(results, "   ", greenColor, yellowColor)
+	for _, result := range results {
+		if result.Comparison == nil {
+			continue
+		}
+		verdictColor := yellowColor
+		if result.Comparison.Significant {
+			verdictColor = redColor
+		}
+		fmt.Printf("   %s: %s\n", result.Version, verdictColor.Sprint(result.Comparison.verdict()))
+	}
+
+	if detailed {
+		fmt.Printf("\n📝 Detailed Execution Log:\n")
+		for _, result := range results {
+			fmt.Printf("\n%s:\n", blueColor.Sprint(result.Version))
+			for i, exec := range result.Executions {
+				status := greenColor.Sprint("✓")
+				if exec.ExitCode != 0 {
+					status = redColor.Sprint("✗")
+				}
+				fmt.Printf("  #%d: %s %s", i+1, status, formatDuration(exec.Duration))
+				if exec.ExitCode != 0 {
+					fmt.Printf(" (exit %d)", exec.ExitCode)
+				}
+				fmt.Printf("\n")
+			}
+		}
+	}
+}
+
+// displayBenchmarkWinner names the fastest version only when it is
+// significantly faster than every other one.
+func displayBenchmarkWinner(results []BenchmarkResult, indent string, greenColor, yellowColor *color.Color) {
+	winner, tied := benchmarkWinner(results)
+	if winner == "" && tied == nil {
+		fmt.Printf("%s%s\n", indent, yellowColor.Sprint("No version had a successful run to compare"))
+		return
+	}
+	if len(results) < 2 {
+		fmt.Printf("%sOnly one version: %s (%s avg)\n", indent, results[0].Version, formatDuration(results[0].AverageTime))
+		return
+	}
+	if winner != "" {
+		var average time.Duration
+		for _, result := range results {
+			if result.Version == winner {
+				average = result.AverageTime
+			}
+		}
+		fmt.Printf("%sFastest: %s (%s avg), significantly faster than every other version\n",
+			indent, greenColor.Sprint(winner), formatDuration(average))
+		return
+	}
+	fmt.Printf("%s%s\n", indent, yellowColor.Sprintf("No clear winner: %s are within noise of each other", strings.Join(tied, ", ")))
+}
+
+// displayBenchmarkRows sorts results by average time and prints one row
+// per version, followed by its confidence interval, outliers and failed
+// runs. Versions without a successful run come last.
+func displayBenchmarkRows(results []BenchmarkResult, benchStats benchmarkStats, greenColor, redColor, blueColor, yellowColor *color.Color) {
 	// Sort by average time
-	sort.Slice(results, func(i, j int) bool {
+	sort.SliceStable(results, func(i, j int) bool {
+		if timedI, timedJ := len(results[i].Samples) > 0, len(results[j].Samples) > 0; timedI != timedJ {
+			return timedI
+		}
 		return results[i].AverageTime < results[j].AverageTime
 	})
 
-	fmt.Printf("%-15s %-12s %-12s %-12s %-12s %-10s\n",
-		"VERSION", "AVG TIME", "MIN TIME", "MAX TIME", "TOTAL TIME", "SUCCESS")
+	fmt.Printf("%-15s %s %-12s %-12s %-12s %-10s\n",
+		"VERSION", padColumn("MEAN ± σ", 22), "MEDIAN", "MIN TIME", "MAX TIME", "SUCCESS")
 	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-	fastest := results[0].AverageTime
-
-	for i, result := range results {
-		var versionColor *color.Color
-		if i == 0 {
+	for _, result := range results {
+		// Only a significantly slower version is marked red
+		versionColor := blueColor
+		if c := result.Comparison; c == nil && len(result.Samples) > 0 {
 			versionColor = greenColor
-		} else if i == len(results)-1 {
+		} else if c != nil && (c.Significant || c.Untimed) {
 			versionColor = redColor
-		} else {
-			versionColor = blueColor
 		}
 
-		speedup := float64(result.AverageTime) / float64(fastest)
 		successColor := greenColor
 		if result.SuccessRate < 100 {
 			successColor = yellowColor
@@ -274,86 +428,154 @@ func displayBenchmarkTable(results []BenchmarkResult, greenColor, redColor, blue
 			successColor = redColor
 		}
 
-		fmt.Printf("%-15s %-12s %-12s %-12s %-12s %s\n",
+		if len(result.Samples) == 0 {
+			fmt.Printf("%-15s %s %-12s %-12s %-12s %s\n", versionColor.Sprint(result.Version), padColumn("-", 22), "-", "-", "-",
+				redColor.Sprintf("%.1f%%", result.SuccessRate))
+			fmt.Printf("%-15s %s\n", "", yellowColor.Sprintf("↳ all %d runs failed, not timed", result.Failed))
+			continue
+		}
+
+		fmt.Printf("%-15s %s %-12s %-12s %-12s %s\n",
 			versionColor.Sprint(result.Version),
-			formatDuration(result.AverageTime),
+			padColumn(formatDuration(result.AverageTime)+" ± "+formatDuration(result.StdDev), 22),
+			formatDuration(result.MedianTime),
 			formatDuration(result.MinTime),
 			formatDuration(result.MaxTime),
-			formatDuration(result.TotalTime),
 			successColor.Sprintf("%.1f%%", result.SuccessRate))
 
-		if i > 0 {
-			fmt.Printf("%-15s %s\n", "", yellowColor.Sprintf("↳ %.2fx slower", speedup))
+		detail := fmt.Sprintf("↳ %s CI %s – %s", formatConfidence(benchStats.Confidence), formatDuration(result.CILow), formatDuration(result.CIHigh))
+		if result.Outliers > 0 {
+			detail += fmt.Sprintf(", %d of %d runs dropped as outliers", result.Outliers, result.Iterations)
 		}
+		if result.Failed > 0 {
+			detail += fmt.Sprintf(", %d failed runs left out of the timings", result.Failed)
+		}
+		fmt.Printf("%-15s %s\n", "", yellowColor.Sprint(detail))
 	}
+}
 
-	fmt.Printf("\n🏆 Performance Summary:\n")
-	fmt.Printf("   Fastest: %s (%s avg)\n",
-		greenColor.Sprint(results[0].Version),
-		formatDuration(results[0].AverageTime))
-
-	if len(results) > 1 {
-		slowest := results[len(results)-1]
-		speedDiff := float64(slowest.AverageTime) / float64(fastest)
-		fmt.Printf("   Slowest: %s (%s avg, %.2fx slower)\n",
-			redColor.Sprint(slowest.Version),
-			formatDuration(slowest.AverageTime),
-			speedDiff)
+// padColumn pads s to width characters; %-*s would count ± and μ as two.
+func padColumn(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
 	}
+	return s
+}
 
-	if detailed {
-		fmt.Printf("\n📝 Detailed Execution Log:\n")
-		for _, result := range results {
-			fmt.Printf("\n%s:\n", blueColor.Sprint(result.Version))
-			for i, exec := range result.Executions {
-				status := greenColor.Sprint("✓")
-				if exec.ExitCode != 0 {
-					status = redColor.Sprint("✗")
-				}
-				fmt.Printf("  #%d: %s %s", i+1, status, formatDuration(exec.Duration))
-				if exec.ExitCode != 0 {
-					fmt.Printf(" (exit %d)", exec.ExitCode)
-				}
-				fmt.Printf("\n")
-			}
+// benchmarkRecord is a BenchmarkResult as written by the json format.
+type benchmarkRecord struct {
+	Version       string                  `json:"version"`
+	Iterations    int                     `json:"iterations"`
+	Warmup        int                     `json:"warmup"`
+	TotalTimeMs   float64                 `json:"total_time_ms"`
+	AverageTimeMs float64                 `json:"average_time_ms"`
+	MedianTimeMs  float64                 `json:"median_time_ms"`
+	StdDevMs      float64                 `json:"stddev_ms"`
+	CILowMs       float64                 `json:"ci_low_ms"`
+	CIHighMs      float64                 `json:"ci_high_ms"`
+	MinTimeMs     float64                 `json:"min_time_ms"`
+	MaxTimeMs     float64                 `json:"max_time_ms"`
+	Outliers      int                     `json:"outliers"`
+	Failed        int                     `json:"failed"`
+	SuccessRate   float64                 `json:"success_rate"`
+	VsFastest     *benchmarkCompareRecord `json:"vs_fastest,omitempty"`
+}
+
+// benchmarkCompareRecord is a BenchmarkComparison as written by the json
+// format. PValue is null when there were too few runs to test.
+type benchmarkCompareRecord struct {
+	Fastest     string   `json:"fastest"`
+	Ratio       float64  `json:"ratio"`
+	PValue      *float64 `json:"p_value"`
+	Significant bool     `json:"significant"`
+}
+
+func newBenchmarkRecord(result BenchmarkResult) benchmarkRecord {
+	record := benchmarkRecord{
+		Version:       result.Version,
+		Iterations:    result.Iterations,
+		Warmup:        result.Warmup,
+		TotalTimeMs:   roundMillis(result.TotalTime),
+		AverageTimeMs: roundMillis(result.AverageTime),
+		MedianTimeMs:  roundMillis(result.MedianTime),
+		StdDevMs:      roundMillis(result.StdDev),
+		CILowMs:       roundMillis(result.CILow),
+		CIHighMs:      roundMillis(result.CIHigh),
+		MinTimeMs:     roundMillis(result.MinTime),
+		MaxTimeMs:     roundMillis(result.MaxTime),
+		Outliers:      result.Outliers,
+		Failed:        result.Failed,
+		SuccessRate:   math.Round(result.SuccessRate*100) / 100,
+	}
+	if c := result.Comparison; c != nil && !c.Untimed {
+		record.VsFastest = &benchmarkCompareRecord{
+			Fastest:     c.Fastest,
+			Ratio:       math.Round(c.Ratio*1000) / 1000,
+			Significant: c.Significant,
+		}
+		if c.Tested {
+			p := c.PValue
+			record.VsFastest.PValue = &p
 		}
 	}
+	return record
 }
 
-func displayBenchmarkJSON(results []BenchmarkResult) {
-	fmt.Printf("{\n")
-	fmt.Printf("  \"benchmark_results\": [\n")
+func newBenchmarkRecords(results []BenchmarkResult) []benchmarkRecord {
+	records := make([]benchmarkRecord, len(results))
 	for i, result := range results {
-		fmt.Printf("    {\n")
-		fmt.Printf("      \"version\": \"%s\",\n", result.Version)
-		fmt.Printf("      \"iterations\": %d,\n", result.Iterations)
-		fmt.Printf("      \"total_time_ms\": %.2f,\n", float64(result.TotalTime.Nanoseconds())/1e6)
-		fmt.Printf("      \"average_time_ms\": %.2f,\n", float64(result.AverageTime.Nanoseconds())/1e6)
-		fmt.Printf("      \"min_time_ms\": %.2f,\n", float64(result.MinTime.Nanoseconds())/1e6)
-		fmt.Printf("      \"max_time_ms\": %.2f,\n", float64(result.MaxTime.Nanoseconds())/1e6)
-		fmt.Printf("      \"success_rate\": %.2f\n", result.SuccessRate)
-		if i < len(results)-1 {
-			fmt.Printf("    },\n")
-		} else {
-			fmt.Printf("    }\n")
-		}
+		records[i] = newBenchmarkRecord(result)
 	}
-	fmt.Printf("  ]\n")
-	fmt.Printf("}\n")
+	return records
 }
 
+// roundMillis converts d to milliseconds with two decimals.
+func roundMillis(d time.Duration) float64 {
+	return math.Round(float64(d.Nanoseconds())/1e4) / 100
+}
+
+func displayBenchmarkJSON(results []BenchmarkResult) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string][]benchmarkRecord{"benchmark_results": newBenchmarkRecords(results)})
+}
+
+const benchmarkCSVHeader = "version,iterations,total_time_ms,average_time_ms,min_time_ms,max_time_ms,success_rate," +
+	"warmup,median_time_ms,stddev_ms,ci_low_ms,ci_high_ms,outliers,vs_fastest_ratio,p_value,significant,failed"
+
 func displayBenchmarkCSV(results []BenchmarkResult) {
-	fmt.Printf("version,iterations,total_time_ms,average_time_ms,min_time_ms,max_time_ms,success_rate\n")
+	fmt.Println(benchmarkCSVHeader)
 	for _, result := range results {
-		fmt.Printf("%s,%d,%.2f,%.2f,%.2f,%.2f,%.2f\n",
-			result.Version,
-			result.Iterations,
-			float64(result.TotalTime.Nanoseconds())/1e6,
-			float64(result.AverageTime.Nanoseconds())/1e6,
-			float64(result.MinTime.Nanoseconds())/1e6,
-			float64(result.MaxTime.Nanoseconds())/1e6,
-			result.SuccessRate)
+		fmt.Println(strings.Join(benchmarkCSVFields(result), ","))
+	}
+}
+
+func benchmarkCSVFields(result BenchmarkResult) []string {
+	fields := []string{
+		result.Version,
+		fmt.Sprint(result.Iterations),
+		fmt.Sprintf("%.2f", float64(result.TotalTime.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.AverageTime.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.MinTime.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.MaxTime.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", result.SuccessRate),
+		fmt.Sprint(result.Warmup),
+		fmt.Sprintf("%.2f", float64(result.MedianTime.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.StdDev.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.CILow.Nanoseconds())/1e6),
+		fmt.Sprintf("%.2f", float64(result.CIHigh.Nanoseconds())/1e6),
+		fmt.Sprint(result.Outliers),
+	}
+	// The fastest version, and untested ones, leave the comparison blank
+	ratio, pValue, significant := "", "", ""
+	if c := result.Comparison; c != nil && !c.Untimed {
+		ratio = fmt.Sprintf("%.3f", c.Ratio)
+		significant = fmt.Sprint(c.Significant)
+		if c.Tested {
+			pValue = fmt.Sprintf("%.4g", c.PValue)
+		}
 	}
+	return append(fields, ratio, pValue, significant, fmt.Sprint(result.Failed))
 }
 
 func formatDuration(d time.Duration) string {
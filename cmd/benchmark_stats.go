@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bhanurp/jfvm/internal/stats"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	testMannWhitney = "mann-whitney"
+	testWelch       = "welch"
+)
+
+// benchmarkStats controls how iterations are summarized and how versions
+// are compared. 1 - Confidence is the significance level of the test.
+type benchmarkStats struct {
+	Confidence   float64
+	Test         string
+	KeepOutliers bool
+}
+
+func benchmarkStatsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "warmup",
+			Usage: "Untimed runs per version before measuring, to warm caches",
+		},
+		&cli.Float64Flag{
+			Name:  "confidence",
+			Usage: "Confidence level for intervals and significance tests",
+			Value: 0.95,
+		},
+		&cli.StringFlag{
+			Name:  "test",
+			Usage: "Significance test between versions: mann-whitney or welch",
+			Value: testMannWhitney,
+		},
+		&cli.BoolFlag{
+			Name:  "keep-outliers",
+			Usage: "Keep runs outside 1.5×IQR of the quartiles in the statistics",
+		},
+	}
+}
+
+func benchmarkStatsFrom(c *cli.Context) (benchmarkStats, error) {
+	s := benchmarkStats{
+		Confidence:   c.Float64("confidence"),
+		Test:         c.String("test"),
+		KeepOutliers: c.Bool("keep-outliers"),
+	}
+	if s.Confidence <= 0 || s.Confidence >= 1 {
+		return s, fmt.Errorf("--confidence must be between 0 and 1, e.g. 0.95")
+	}
+	if s.Test != testMannWhitney && s.Test != testWelch {
+		return s, fmt.Errorf("unknown test %q: use mann-whitney or welch", s.Test)
+	}
+	if c.Int("warmup") < 0 {
+		return s, fmt.Errorf("--warmup must not be negative")
+	}
+	return s, nil
+}
+
+// testName is how the summary names the test.
+func (s benchmarkStats) testName() string {
+	if s.Test == testWelch {
+		return "Welch's t-test"
+	}
+	return "Mann-Whitney U test"
+}
+
+// pValue runs the configured test on two sets of samples.
+func (s benchmarkStats) pValue(a, b []float64) float64 {
+	if s.Test == testWelch {
+		return stats.Welch(a, b)
+	}
+	return stats.MannWhitney(a, b)
+}
+
+// summarize fills in the result's statistics from the durations of its
+// successful runs: mean, median, standard deviation and confidence interval
+// over the runs that are not outliers, and min and max over all of them.
+func (s benchmarkStats) summarize(result *BenchmarkResult, durations []float64) {
+	if len(durations) == 0 {
+		return
+	}
+
+	sorted := stats.Sorted(durations)
+	result.MinTime = time.Duration(sorted[0])
+	result.MaxTime = time.Duration(sorted[len(sorted)-1])
+
+	kept := sorted
+	if !s.KeepOutliers {
+		var outliers []float64
+		kept, outliers = stats.RemoveOutliers(sorted)
+		result.Outliers = len(outliers)
+	}
+	low, high := stats.MeanCI(kept, s.Confidence)
+
+	result.Samples = kept
+	result.AverageTime = time.Duration(stats.Mean(kept))
+	result.MedianTime = time.Duration(stats.Median(kept))
+	result.StdDev = time.Duration(stats.StdDev(kept))
+	result.CILow = time.Duration(math.Max(low, 0))
+	result.CIHigh = time.Duration(high)
+}
+
+// BenchmarkComparison is how a version did against the fastest one.
+type BenchmarkComparison struct {
+	Fastest     string
+	Ratio       float64 // mean time relative to the fastest
+	PValue      float64
+	Tested      bool // false when either side has fewer than two samples
+	Significant bool
+	Untimed     bool // every run failed, so there is no time to compare
+}
+
+// compareWithFastest sets every result's Comparison against the version
+// with the lowest mean time among those with a successful run. Result
+// order is unchanged.
+func (s benchmarkStats) compareWithFastest(results []BenchmarkResult) {
+	fastest := -1
+	for i, result := range results {
+		if len(result.Samples) > 0 && (fastest < 0 || result.AverageTime < results[fastest].AverageTime) {
+			fastest = i
+		}
+	}
+
+	var base BenchmarkResult
+	if fastest >= 0 {
+		base = results[fastest]
+	}
+	for i := range results {
+		if i == fastest {
+			results[i].Comparison = nil
+			continue
+		}
+		if len(results[i].Samples) == 0 {
+			results[i].Comparison = &BenchmarkComparison{Fastest: base.Version, PValue: 1, Untimed: true}
+			continue
+		}
+		c := &BenchmarkComparison{Fastest: base.Version, PValue: 1}
+		if base.AverageTime > 0 {
+			c.Ratio = float64(results[i].AverageTime) / float64(base.AverageTime)
+		}
+		if len(base.Samples) >= 2 && len(results[i].Samples) >= 2 {
+			c.Tested = true
+			c.PValue = s.pValue(base.Samples, results[i].Samples)
+			c.Significant = c.PValue < 1-s.Confidence
+		}
+		results[i].Comparison = c
+	}
+}
+
+// benchmarkWinner returns the fastest version when it is significantly
+// faster than every other one that has timings. Otherwise it returns the
+// versions that are statistically tied for fastest. Both are empty when no
+// version had a successful run.
+func benchmarkWinner(results []BenchmarkResult) (winner string, tied []string) {
+	var fastest string
+	for _, result := range results {
+		if result.Comparison == nil {
+			fastest = result.Version
+		}
+	}
+	if fastest == "" {
+		return "", nil
+	}
+	tied = []string{fastest}
+	for _, result := range results {
+		if c := result.Comparison; c != nil && !c.Untimed && !c.Significant {
+			tied = append(tied, result.Version)
+		}
+	}
+	if len(tied) == 1 {
+		return fastest, nil
+	}
+	return "", tied
+}
+
+// verdict describes a comparison for the summary.
+func (c *BenchmarkComparison) verdict() string {
+	switch {
+	case c.Untimed:
+		return "every run failed, not timed"
+	case !c.Tested:
+		return fmt.Sprintf("%.2fx slower than %s, too few runs to test", c.Ratio, c.Fastest)
+	case c.Significant:
+		return fmt.Sprintf("%.2fx slower than %s (p = %s)", c.Ratio, c.Fastest, formatPValue(c.PValue))
+	}
+	return fmt.Sprintf("%.2fx the time of %s, not significant (p = %s)", c.Ratio, c.Fastest, formatPValue(c.PValue))
+}
+
+func formatPValue(p float64) string {
+	if p < 0.001 {
+		return "<0.001"
+	}
+	return fmt.Sprintf("%.3f", p)
+}
+
+// formatConfidence renders 0.95 as "95%".
+func formatConfidence(confidence float64) string {
+	return fmt.Sprintf("%g%%", math.Round(confidence*1000)/10)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunBenchmarkRoundsInterleavesAndDropsFailedRuns(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "runs.log")
+	fakeVersions(t, map[string]string{
+		"2.74.0": "echo 2.74.0 >> " + log + "\n",
+		// The third run, counting the warmup, fails
+		"2.75.0": "echo 2.75.0 >> " + log + "\n[ \"$(grep -c 2.75.0 " + log + ")\" -ne 3 ]\n",
+	})
+
+	task := benchmarkTask{
+		Command:    []string{"rt", "ping"},
+		Iterations: 3,
+		Warmup:     1,
+		Timeout:    10 * time.Second,
+		Stats:      benchmarkStats{Confidence: 0.95, Test: testMannWhitney},
+	}
+	versions := []string{"2.74.0", "2.75.0"}
+	results := runBenchmarkRounds(versions, []benchmarkTask{task, task})
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := strings.Fields(string(data))
+	want := []string{"2.74.0", "2.75.0", "2.74.0", "2.75.0", "2.74.0", "2.75.0", "2.74.0", "2.75.0"}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("run order = %v, want one run of each version per round", runs)
+	}
+
+	ok, failing := results[0], results[1]
+	if ok.Failed != 0 || len(ok.Samples) != 3 || ok.SuccessRate != 100 {
+		t.Errorf("2.74.0: failed %d, %d samples, success %.1f%%; want 0, 3, 100%%", ok.Failed, len(ok.Samples), ok.SuccessRate)
+	}
+	if len(failing.Executions) != 3 || failing.Failed != 1 || len(failing.Samples) != 2 {
+		t.Errorf("2.75.0: %d runs, %d failed, %d samples; want 3, 1, 2", len(failing.Executions), failing.Failed, len(failing.Samples))
+	}
+	if failing.Executions[1].ExitCode == 0 {
+		t.Errorf("2.75.0 run 2 exit code = 0, want the failing run")
+	}
+	for _, sample := range failing.Samples {
+		if sample == float64(failing.Executions[1].Duration) {
+			t.Errorf("failed run's duration %v is in the samples", failing.Executions[1].Duration)
+		}
+	}
+}
+
+func TestCompareWithFastestSkipsUntimedVersions(t *testing.T) {
+	s := benchmarkStats{Confidence: 0.95, Test: testWelch}
+	results := []BenchmarkResult{
+		{Version: "2.76.0", Failed: 3},
+		{Version: "2.74.0", AverageTime: 20, Samples: []float64{19, 20, 21}},
+		{Version: "2.75.0", AverageTime: 40, Samples: []float64{39, 40, 41}},
+	}
+	s.compareWithFastest(results)
+
+	if c := results[0].Comparison; c == nil || !c.Untimed || c.Fastest != "2.74.0" {
+		t.Errorf("2.76.0 comparison = %+v, want untimed against 2.74.0", c)
+	}
+	if results[1].Comparison != nil {
+		t.Errorf("2.74.0 comparison = %+v, want nil for the fastest", results[1].Comparison)
+	}
+	if winner, tied := benchmarkWinner(results); winner != "2.74.0" || tied != nil {
+		t.Errorf("winner = %q, tied = %v; want 2.74.0", winner, tied)
+	}
+
+	untimed := []BenchmarkResult{{Version: "2.74.0", Failed: 2}, {Version: "2.75.0", Failed: 2}}
+	s.compareWithFastest(untimed)
+	if winner, tied := benchmarkWinner(untimed); winner != "" || tied != nil {
+		t.Errorf("no timings: winner = %q, tied = %v; want neither", winner, tied)
+	}
+}
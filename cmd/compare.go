@@ -12,6 +12,7 @@ import (
 
 	"github.com/bhanurp/jfvm/cmd/descriptions"
 	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal/diff"
 	"github.com/fatih/color"
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/urfave/cli/v2"
@@ -23,17 +24,76 @@ type ExecutionResult struct {
 	Command   string
 	Output    string
 	ErrorMsg  string
+	Stderr    string
 	ExitCode  int
+	TimedOut  bool
 	Duration  time.Duration
 	StartTime time.Time
 }
 
+const (
+	modeText = "text"
+	modeJSON = "json"
+)
+
+// diffOptions controls how two outputs are compared and rendered. It is
+// shared by compare and history replay.
+type diffOptions struct {
+	Unified    bool
+	NoColor    bool
+	ShowTiming bool
+	Mode       string
+	Mask       *diff.Mask
+}
+
+// diffFlags are the comparison flags read by diffOptionsFrom, next to the
+// unified, no-color and timing flags each command declares.
+func diffFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mode",
+			Usage: "Comparison mode: text, or json for path-level differences that ignore key order",
+			Value: modeText,
+		},
+		&cli.StringSliceFlag{
+			Name:  "ignore-regex",
+			Usage: "Mask text matching a regular expression before comparing (repeatable)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "ignore-path",
+			Usage: "With --mode json, ignore a JSON path such as $.files[*].modified or $..created (repeatable)",
+		},
+	}
+}
+
+func diffOptionsFrom(c *cli.Context) (diffOptions, error) {
+	opts := diffOptions{
+		Unified:    c.Bool("unified"),
+		NoColor:    c.Bool("no-color"),
+		ShowTiming: c.Bool("timing"),
+		Mode:       c.String("mode"),
+	}
+	if opts.Mode != modeText && opts.Mode != modeJSON {
+		return opts, fmt.Errorf("unknown --mode %q: use text or json", opts.Mode)
+	}
+
+	mask, err := diff.NewMask(c.StringSlice("ignore-regex"), c.StringSlice("ignore-path"))
+	if err != nil {
+		return opts, err
+	}
+	if mask.HasPaths() && opts.Mode != modeJSON {
+		return opts, fmt.Errorf("--ignore-path requires --mode json")
+	}
+	opts.Mask = mask
+	return opts, nil
+}
+
 var Compare = &cli.Command{
 	Name:        "compare",
 	Usage:       descriptions.Compare.Usage,
-	ArgsUsage:   "<version1> <version2> -- <jf-command> [args...]",
+	ArgsUsage:   "<version1> <version2> | <version1,version2,...> -- <jf-command> [args...] | --scenario <file> <versions>",
 	Description: descriptions.Compare.Format(),
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:  "unified",
 			Usage: "Show unified diff format instead of side-by-side",
@@ -54,87 +114,180 @@ var Compare = &cli.Command{
 			Usage: "Show execution timing information",
 			Value: true,
 		},
-	},
+		&cli.StringFlag{
+			Name:  "baseline",
+			Usage: "Version the others are diffed against (default: the first one)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: table, json, junit, markdown",
+			Value: "table",
+		},
+		&cli.BoolFlag{
+			Name:  "fail-on-diff",
+			Usage: "Exit with status 1 if any version's output differs from the baseline",
+		},
+		&cli.BoolFlag{
+			Name:  "fail-on-exit-diff",
+			Usage: "Exit with status 1 if any version's exit code differs from the baseline",
+		},
+		&cli.BoolFlag{
+			Name:  "show-diffs",
+			Usage: "With --scenario, show the diff of every failing command",
+		},
+	}, append(append(diffFlags(), scenarioFlags()...), isolateFlags()...)...),
 	Action: func(c *cli.Context) error {
-		args := c.Args().Slice()
-		if len(args) < 3 {
-			return cli.Exit("Usage: jfvm compare <version1> <version2> -- <jf-command> [args...]", 1)
+		opts, err := diffOptionsFrom(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
 		}
 
-		// Find the separator "--"
-		separatorIndex := -1
-		for i, arg := range args {
-			if arg == "--" {
-				separatorIndex = i
-				break
-			}
+		format := c.String("format")
+		switch format {
+		case "table", "json", "junit", "markdown":
+		default:
+			return cli.Exit(fmt.Sprintf("Unknown format %q: use table, json, junit or markdown", format), 1)
 		}
 
-		if separatorIndex == -1 || separatorIndex != 2 {
-			return cli.Exit("Missing '--' separator. Usage: jfvm compare <version1> <version2> -- <jf-command> [args...]", 1)
+		if path := c.String("scenario"); path != "" {
+			return runCompareScenario(c, path, opts, format)
 		}
 
-		version1 := args[0]
-		version2 := args[1]
-		jfCommand := args[3:]
-
-		if len(jfCommand) == 0 {
-			return cli.Exit("No JFrog CLI command specified after '--'", 1)
+		versions, jfCommand, err := parseCompareArguments(c.Args().Slice())
+		if err != nil {
+			return err
 		}
 
-		// Resolve aliases if needed
-		resolved1, err := utils.ResolveVersionOrAlias(version1)
+		// Resolve aliases, keywords and ranges against installed versions
+		resolved, err := validateVersions(versions)
 		if err != nil {
-			resolved1 = version1
+			return err
 		}
-		resolved2, err := utils.ResolveVersionOrAlias(version2)
+
+		baseline, err := findBaseline(c.String("baseline"), versions, resolved)
 		if err != nil {
-			resolved2 = version2
+			return err
 		}
 
-		// Check if versions exist
-		if err := utils.CheckVersionExists(resolved1); err != nil {
-			return fmt.Errorf("version %s (%s) not found: %w", version1, resolved1, err)
+		sb, err := newSandboxes(c.Bool("isolate"), c.Bool("isolate-workdir"), resolved)
+		if err != nil {
+			return err
 		}
-		if err := utils.CheckVersionExists(resolved2); err != nil {
-			return fmt.Errorf("version %s (%s) not found: %w", version2, resolved2, err)
+		defer sb.Remove()
+
+		// Only show headers for table format
+		if format == "table" {
+			fmt.Printf("🔄 Comparing JFrog CLI versions: %s\n", strings.Join(versions, " vs "))
+			fmt.Printf("📝 Command: jf %s\n", strings.Join(jfCommand, " "))
+			if sb != nil {
+				fmt.Println(sb.describe())
+			}
+			fmt.Println()
 		}
 
-		fmt.Printf("🔄 Comparing JFrog CLI versions: %s vs %s\n", version1, version2)
-		fmt.Printf("📝 Command: jf %s\n\n", strings.Join(jfCommand, " "))
-
 		// Execute commands in parallel
-		results := make([]ExecutionResult, 2)
+		results := make([]ExecutionResult, len(resolved))
 		g, ctx := errgroup.WithContext(context.Background())
 
 		timeout := time.Duration(c.Int("timeout")) * time.Second
 		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
-		g.Go(func() error {
-			result, err := executeJFCommand(timeoutCtx, resolved1, jfCommand)
-			results[0] = result
-			return err
-		})
-
-		g.Go(func() error {
-			result, err := executeJFCommand(timeoutCtx, resolved2, jfCommand)
-			results[1] = result
-			return err
-		})
+		for i, version := range resolved {
+			i, version := i, version
+			g.Go(func() error {
+				result, err := executeJFCommandWith(timeoutCtx, version, jfCommand, sb.exec(i, execOptions{}))
+				results[i] = sb.normalize(i, result)
+				return err
+			})
+		}
 
 		if err := g.Wait(); err != nil {
 			fmt.Fprintf(os.Stderr, "⚠️  Warning: %v\n\n", err)
 		}
 
 		// Display results
-		displayComparison(results[0], results[1], c.Bool("unified"), c.Bool("no-color"), c.Bool("timing"))
+		report := buildCompareReport(versions, results, baseline, jfCommand, opts)
+		switch format {
+		case "json":
+			if err := displayCompareJSON(report); err != nil {
+				return err
+			}
+		case "junit":
+			if err := displayCompareJUnit(report); err != nil {
+				return err
+			}
+		case "markdown":
+			displayCompareMarkdown(report)
+		default:
+			if len(results) == 2 {
+				displayComparison(results[baseline], results[1-baseline], opts)
+			} else {
+				displayNWayComparison(results, baseline, opts)
+			}
+		}
 
+		if c.Bool("fail-on-exit-diff") && report.ExitCodesDiffer {
+			return cli.Exit("", 1)
+		}
+		if c.Bool("fail-on-diff") && report.OutputsDiffer {
+			return cli.Exit("", 1)
+		}
 		return nil
 	},
 }
 
-func executeJFCommand(ctx context.Context, version string, jfCommand []string) (ExecutionResult, error) {
+// parseCompareArguments accepts versions as separate arguments
+// (2.74.0 2.75.0) or comma-separated (2.70.0,2.72.0,latest) before "--".
+func parseCompareArguments(args []string) (versions []string, jfCommand []string, err error) {
+	separatorIndex := -1
+	for i, arg := range args {
+		if arg == "--" {
+			separatorIndex = i
+			break
+		}
+	}
+
+	if separatorIndex == -1 {
+		return nil, nil, cli.Exit("Missing '--' separator. Usage: jfvm compare <version1> <version2> -- <jf-command> [args...]", 1)
+	}
+
+	versions = splitVersions(args[:separatorIndex])
+	if len(versions) < 2 {
+		return nil, nil, cli.Exit("At least two versions are required. Usage: jfvm compare <version1> <version2> -- <jf-command> [args...]", 1)
+	}
+
+	jfCommand = args[separatorIndex+1:]
+	if len(jfCommand) == 0 {
+		return nil, nil, cli.Exit("No JFrog CLI command specified after '--'", 1)
+	}
+
+	return versions, jfCommand, nil
+}
+
+// findBaseline returns the index of the baseline among the requested or
+// resolved versions; an empty name means the first one.
+func findBaseline(name string, requested, resolved []string) (int, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for i := range requested {
+		if requested[i] == name || resolved[i] == name {
+			return i, nil
+		}
+	}
+	return 0, cli.Exit(fmt.Sprintf("Baseline %s is not one of the compared versions", name), 1)
+}
+
+// execOptions adjusts how jf runs. The zero value runs it in the current
+// directory with the caller's environment and no stdin.
+type execOptions struct {
+	Dir   string
+	Env   []string // KEY=VALUE pairs added to the caller's environment
+	Stdin []byte
+}
+
+func executeJFCommandWith(ctx context.Context, version string, jfCommand []string, opts execOptions) (ExecutionResult, error) {
 	result := ExecutionResult{
 		Version:   version,
 		Command:   strings.Join(jfCommand, " "),
@@ -144,6 +297,13 @@ func executeJFCommand(ctx context.Context, version string, jfCommand []string) (
 	binPath := filepath.Join(utils.JfvmVersions, version, utils.BinaryName)
 
 	cmd := exec.CommandContext(ctx, binPath, jfCommand...)
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	if opts.Stdin != nil {
+		cmd.Stdin = bytes.NewReader(opts.Stdin)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -151,6 +311,7 @@ func executeJFCommand(ctx context.Context, version string, jfCommand []string) (
 
 	err := cmd.Run()
 	result.Duration = time.Since(result.StartTime)
+	result.TimedOut = ctx.Err() == context.DeadlineExceeded
 
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
@@ -162,11 +323,12 @@ func executeJFCommand(ctx context.Context, version string, jfCommand []string) (
 	}
 
 	result.Output = stdout.String()
+	result.Stderr = stderr.String()
 
 	return result, nil
 }
 
-func displayComparison(result1, result2 ExecutionResult, unified, noColor, showTiming bool) {
+func displayComparison(result1, result2 ExecutionResult, opts diffOptions) {
 	// Setup colors
 	var (
 		redColor   = color.New(color.FgRed)
@@ -174,7 +336,7 @@ func displayComparison(result1, result2 ExecutionResult, unified, noColor, showT
 		blueColor  = color.New(color.FgBlue)
 	)
 
-	if noColor {
+	if opts.NoColor {
 		color.NoColor = true
 	}
 
@@ -184,7 +346,7 @@ func displayComparison(result1, result2 ExecutionResult, unified, noColor, showT
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n\n")
 
 	// Display timing information
-	if showTiming {
+	if opts.ShowTiming {
 		fmt.Printf("⏱️  EXECUTION TIMING:\n")
 		fmt.Printf("   Version %s: %v\n", blueColor.Sprint(result1.Version), result1.Duration)
 		fmt.Printf("   Version %s: %v\n", blueColor.Sprint(result2.Version), result2.Duration)
@@ -219,9 +381,22 @@ func displayComparison(result1, result2 ExecutionResult, unified, noColor, showT
 		fmt.Printf("\n")
 	}
 
-	// Compare outputs
-	output1 := strings.TrimSpace(result1.Output)
-	output2 := strings.TrimSpace(result2.Output)
+	displayOutputDiff(result1, result2, opts)
+}
+
+// displayOutputDiff compares the stdout of two results, as JSON changes or
+// a text diff depending on opts.
+func displayOutputDiff(result1, result2 ExecutionResult, opts diffOptions) {
+	if opts.Mode == modeJSON {
+		if changes, ok := jsonChanges(result1.Output, result2.Output, opts.Mask); ok {
+			displayJSONChanges(changes, result1.Version, result2.Version)
+			return
+		}
+		fmt.Printf("⚠️  Output is not JSON, falling back to a text diff\n\n")
+	}
+
+	output1 := opts.Mask.Text(strings.TrimSpace(result1.Output))
+	output2 := opts.Mask.Text(strings.TrimSpace(result2.Output))
 
 	if output1 == output2 {
 		fmt.Printf("✅ OUTPUTS ARE IDENTICAL\n")
@@ -233,10 +408,61 @@ func displayComparison(result1, result2 ExecutionResult, unified, noColor, showT
 
 	fmt.Printf("📊 OUTPUT DIFFERENCES:\n")
 
-	if unified {
-		displayUnifiedDiff(output1, output2, result1.Version, result2.Version, noColor)
+	if opts.Unified {
+		displayUnifiedDiff(output1, output2, result1.Version, result2.Version, opts.NoColor)
 	} else {
-		displaySideBySideDiff(output1, output2, result1.Version, result2.Version, noColor)
+		displaySideBySideDiff(output1, output2, result1.Version, result2.Version, opts.NoColor)
+	}
+}
+
+// jsonChanges diffs two outputs as JSON; ok is false when either is not
+// JSON.
+func jsonChanges(output1, output2 string, mask *diff.Mask) ([]diff.Change, bool) {
+	doc1, err := diff.ParseJSON(output1)
+	if err != nil {
+		return nil, false
+	}
+	doc2, err := diff.ParseJSON(output2)
+	if err != nil {
+		return nil, false
+	}
+	return diff.JSON(doc1, doc2, mask), true
+}
+
+// outputsEqual reports whether two outputs match under the diff options,
+// the same way displayComparison decides.
+func outputsEqual(output1, output2 string, opts diffOptions) bool {
+	if opts.Mode == modeJSON {
+		if changes, ok := jsonChanges(output1, output2, opts.Mask); ok {
+			return len(changes) == 0
+		}
+	}
+	return opts.Mask.Text(strings.TrimSpace(output1)) == opts.Mask.Text(strings.TrimSpace(output2))
+}
+
+func displayJSONChanges(changes []diff.Change, version1, version2 string) {
+	var (
+		redColor    = color.New(color.FgRed)
+		greenColor  = color.New(color.FgGreen)
+		yellowColor = color.New(color.FgYellow)
+	)
+
+	if len(changes) == 0 {
+		fmt.Printf("✅ JSON OUTPUTS ARE EQUIVALENT\n")
+		return
+	}
+
+	fmt.Printf("📊 JSON DIFFERENCES (%d, %s → %s):\n", len(changes), version1, version2)
+	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+	for _, change := range changes {
+		switch change.Kind {
+		case diff.Added:
+			greenColor.Printf("%s\n", change)
+		case diff.Removed:
+			redColor.Printf("%s\n", change)
+		default:
+			yellowColor.Printf("%s\n", change)
+		}
 	}
 }
 
@@ -275,13 +501,7 @@ func displayUnifiedDiff(output1, output2, version1, version2 string, noColor boo
 }
 
 func displaySideBySideDiff(output1, output2, version1, version2 string, noColor bool) {
-	lines1 := strings.Split(output1, "\n")
-	lines2 := strings.Split(output2, "\n")
-
-	maxLines := len(lines1)
-	if len(lines2) > maxLines {
-		maxLines = len(lines2)
-	}
+	rows := diff.SideBySide(diff.Lines(diff.SplitLines(output1), diff.SplitLines(output2)))
 
 	var (
 		blueColor  = color.New(color.FgBlue)
@@ -294,16 +514,9 @@ func displaySideBySideDiff(output1, output2, version1, version2 string, noColor
 	fmt.Printf("%-40s │ %-40s\n", blueColor.Sprintf("%s", version1), blueColor.Sprintf("%s", version2))
 	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-	for i := 0; i < maxLines; i++ {
-		line1 := ""
-		line2 := ""
-
-		if i < len(lines1) {
-			line1 = lines1[i]
-		}
-		if i < len(lines2) {
-			line2 = lines2[i]
-		}
+	for _, row := range rows {
+		line1 := row.Old
+		line2 := row.New
 
 		// Truncate long lines for display
 		if len(line1) > 38 {
@@ -316,27 +529,36 @@ func displaySideBySideDiff(output1, output2, version1, version2 string, noColor
 		marker1 := " "
 		marker2 := " "
 
-		if line1 != line2 {
-			if line1 != "" && line2 == "" {
+		if row.Changed {
+			switch {
+			case row.HasOld && !row.HasNew:
 				marker1 = "-"
-				if !noColor {
-					line1 = redColor.Sprint(line1)
-				}
-			} else if line1 == "" && line2 != "" {
+			case !row.HasOld && row.HasNew:
 				marker2 = "+"
-				if !noColor {
-					line2 = greenColor.Sprint(line2)
-				}
-			} else {
+			default:
 				marker1 = "~"
 				marker2 = "~"
-				if !noColor {
-					line1 = redColor.Sprint(line1)
-					line2 = greenColor.Sprint(line2)
-				}
+			}
+			if !noColor {
+				line1 = redColor.Sprint(line1)
+				line2 = greenColor.Sprint(line2)
 			}
 		}
 
-		fmt.Printf("%s%-39s │ %s%-39s\n", marker1, line1, marker2, line2)
+		// Colored lines are padded by their plain text width, so escape codes
+		// don't break the columns
+		fmt.Printf("%s%s │ %s%s\n", marker1, padRight(line1, row.Old, 39), marker2, line2)
+	}
+}
+
+// padRight pads a possibly colored string to width based on its plain text.
+func padRight(s, plain string, width int) string {
+	visible := len(plain)
+	if visible > 38 {
+		visible = 38
+	}
+	if visible >= width {
+		return s
 	}
+	return s + strings.Repeat(" ", width-visible)
 }
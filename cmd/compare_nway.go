@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// equivalenceClasses groups results that behave the same (exit code and
+// stdout under opts), in order of first appearance. Each class holds
+// indexes into results.
+func equivalenceClasses(results []ExecutionResult, opts diffOptions) [][]int {
+	var classes [][]int
+	for i, result := range results {
+		placed := false
+		for c, class := range classes {
+			if sameBehavior(results[class[0]], result, opts) {
+				classes[c] = append(classes[c], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			classes = append(classes, []int{i})
+		}
+	}
+	return classes
+}
+
+func sameBehavior(a, b ExecutionResult, opts diffOptions) bool {
+	return a.ExitCode == b.ExitCode && outputsEqual(a.Output, b.Output, opts)
+}
+
+// classLabel names classes A, B, ... Z, then 27, 28, ...
+func classLabel(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// displayNWayComparison shows how three or more versions group by
+// behavior and diffs one representative of each group against the
+// baseline.
+func displayNWayComparison(results []ExecutionResult, baseline int, opts diffOptions) {
+	var (
+		redColor   = color.New(color.FgRed)
+		greenColor = color.New(color.FgGreen)
+		blueColor  = color.New(color.FgBlue)
+	)
+
+	if opts.NoColor {
+		color.NoColor = true
+	}
+
+	classes := equivalenceClasses(results, opts)
+	classOf := make([]int, len(results))
+	for c, class := range classes {
+		for _, i := range class {
+			classOf[i] = c
+		}
+	}
+	baselineClass := classOf[baseline]
+
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+	fmt.Printf("🔍 COMPARISON RESULTS (%d versions, baseline %s)\n", len(results), results[baseline].Version)
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n\n")
+
+	fmt.Printf("%-20s %-7s %-6s", "VERSION", "CLASS", "EXIT")
+	if opts.ShowTiming {
+		fmt.Printf(" %s", "DURATION")
+	}
+	fmt.Printf("\n")
+	for i, result := range results {
+		exit := greenColor.Sprintf("%-6s", "✓ 0")
+		if result.ExitCode != 0 {
+			exit = redColor.Sprintf("%-6s", fmt.Sprintf("✗ %d", result.ExitCode))
+		}
+		fmt.Printf("%s %-7s %s", blueColor.Sprintf("%-20s", result.Version), classLabel(classOf[i]), exit)
+		if opts.ShowTiming {
+			fmt.Printf(" %v", result.Duration)
+		}
+		fmt.Printf("\n")
+	}
+	fmt.Printf("\n")
+
+	fmt.Printf("🧩 EQUIVALENCE CLASSES:\n")
+	for c, class := range classes {
+		versions := make([]string, len(class))
+		for k, i := range class {
+			versions[k] = results[i].Version
+			if i == baseline {
+				versions[k] += " (baseline)"
+			}
+		}
+		verb := "identical"
+		if len(class) == 1 {
+			verb = "unique"
+		}
+		if c != baselineClass {
+			verb += ", differs from baseline"
+		}
+		fmt.Printf("   %s: %s — %s\n", classLabel(c), strings.Join(versions, ", "), verb)
+	}
+	fmt.Printf("\n")
+
+	if len(classes) == 1 {
+		output := opts.Mask.Text(strings.TrimSpace(results[baseline].Output))
+		fmt.Printf("✅ ALL %d VERSIONS BEHAVE IDENTICALLY\n", len(results))
+		fmt.Printf("📄 Output (%d lines):\n", len(strings.Split(output, "\n")))
+		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+		fmt.Printf("%s\n", output)
+		return
+	}
+
+	// Consecutive versions in different classes are where behavior changed
+	fmt.Printf("🔀 BEHAVIOR CHANGES (in the order given):\n")
+	for i := 1; i < len(results); i++ {
+		if classOf[i] != classOf[i-1] {
+			fmt.Printf("   %s → %s (%s → %s)\n", results[i-1].Version, results[i].Version, classLabel(classOf[i-1]), classLabel(classOf[i]))
+		}
+	}
+	fmt.Printf("\n")
+
+	base := results[baseline]
+	for c, class := range classes {
+		if c == baselineClass {
+			continue
+		}
+		other := results[class[0]]
+		fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+		fmt.Printf("📊 CLASS %s (%s) vs BASELINE %s\n", classLabel(c), other.Version, base.Version)
+		fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+
+		if other.ExitCode != base.ExitCode {
+			fmt.Printf("🚨 EXIT CODE DIFFERENCE: %s %d → %s %d\n", base.Version, base.ExitCode, other.Version, other.ExitCode)
+			if other.ErrorMsg != "" {
+				fmt.Printf("   %s ERROR:\n%s\n", redColor.Sprint(other.Version), other.ErrorMsg)
+			}
+		}
+		if outputsEqual(base.Output, other.Output, opts) {
+			fmt.Printf("✅ Output matches the baseline\n\n")
+			continue
+		}
+		displayOutputDiff(base, other, opts)
+		fmt.Printf("\n")
+	}
+}
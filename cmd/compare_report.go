@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bhanurp/jfvm/internal/diff"
+)
+
+// hunkContext is the number of unchanged lines around each diff hunk.
+const hunkContext = 3
+
+// compareReport is the machine-readable result of `jfvm compare`.
+type compareReport struct {
+	Command         string          `json:"command"`
+	Mode            string          `json:"mode"`
+	Baseline        string          `json:"baseline"`
+	BaselineIndex   int             `json:"baseline_index"`
+	Results         []compareResult `json:"results"`
+	Classes         [][]string      `json:"classes"`
+	Diffs           []compareDiff   `json:"diffs"`
+	OutputsDiffer   bool            `json:"outputs_differ"`
+	ExitCodesDiffer bool            `json:"exit_codes_differ"`
+
+	// Set by scenarios that expect an exit code
+	ExpectedExitCode *int `json:"expected_exit_code,omitempty"`
+	UnexpectedExit   bool `json:"unexpected_exit,omitempty"`
+}
+
+type compareResult struct {
+	Version    string  `json:"version"`
+	Requested  string  `json:"requested"`
+	ExitCode   int     `json:"exit_code"`
+	DurationMs float64 `json:"duration_ms"`
+	Stdout     string  `json:"stdout"`
+	Stderr     string  `json:"stderr"`
+
+	UnexpectedExit bool `json:"unexpected_exit,omitempty"`
+	TimedOut       bool `json:"timed_out,omitempty"`
+}
+
+// compareDiff compares one version with the baseline. Text output gets
+// unified diff hunks; JSON output in --mode json gets path-level changes.
+// Index is the version's position in Results: the same version can be
+// compared twice, e.g. as latest and by number.
+type compareDiff struct {
+	Index           int           `json:"index"`
+	Version         string        `json:"version"`
+	Baseline        string        `json:"baseline"`
+	OutputDiffers   bool          `json:"output_differs"`
+	ExitCodeDiffers bool          `json:"exit_code_differs"`
+	Hunks           []diff.Hunk   `json:"hunks,omitempty"`
+	Changes         []diff.Change `json:"changes,omitempty"`
+}
+
+func buildCompareReport(requested []string, results []ExecutionResult, baseline int, jfCommand []string, opts diffOptions) compareReport {
+	base := results[baseline]
+	report := compareReport{
+		Command:       strings.Join(jfCommand, " "),
+		Mode:          opts.Mode,
+		Baseline:      base.Version,
+		BaselineIndex: baseline,
+		Results:       make([]compareResult, len(results)),
+		Classes:       [][]string{},
+		Diffs:         []compareDiff{},
+	}
+
+	for i, result := range results {
+		report.Results[i] = compareResult{
+			Version:    result.Version,
+			Requested:  requested[i],
+			ExitCode:   result.ExitCode,
+			DurationMs: float64(result.Duration.Microseconds()) / 1000,
+			Stdout:     result.Output,
+			Stderr:     result.Stderr,
+			TimedOut:   result.TimedOut,
+		}
+	}
+
+	for _, class := range equivalenceClasses(results, opts) {
+		versions := make([]string, len(class))
+		for k, i := range class {
+			versions[k] = results[i].Version
+		}
+		report.Classes = append(report.Classes, versions)
+	}
+
+	for i, result := range results {
+		if i == baseline {
+			continue
+		}
+		d := compareDiff{
+			Index:           i,
+			Version:         result.Version,
+			Baseline:        base.Version,
+			OutputDiffers:   !outputsEqual(base.Output, result.Output, opts),
+			ExitCodeDiffers: base.ExitCode != result.ExitCode,
+		}
+		if d.OutputDiffers {
+			changes, ok := []diff.Change(nil), false
+			if opts.Mode == modeJSON {
+				changes, ok = jsonChanges(base.Output, result.Output, opts.Mask)
+			}
+			if ok {
+				d.Changes = changes
+			} else {
+				d.Hunks = textHunks(base.Output, result.Output, opts.Mask)
+			}
+		}
+		report.OutputsDiffer = report.OutputsDiffer || d.OutputDiffers
+		report.ExitCodesDiffer = report.ExitCodesDiffer || d.ExitCodeDiffers
+		report.Diffs = append(report.Diffs, d)
+	}
+	return report
+}
+
+// expectExit flags results whose exit code is not the expected one.
+func (r *compareReport) expectExit(code int) {
+	r.ExpectedExitCode = &code
+	for i := range r.Results {
+		if r.Results[i].ExitCode != code {
+			r.Results[i].UnexpectedExit = true
+			r.UnexpectedExit = true
+		}
+	}
+}
+
+// label names a result in reports, with the requested version when it
+// resolved to something else, so `latest` and its number stay apart.
+func (r compareResult) label() string {
+	if r.Requested == "" || r.Requested == r.Version {
+		return r.Version
+	}
+	return fmt.Sprintf("%s (%s)", r.Version, r.Requested)
+}
+
+func textHunks(output1, output2 string, mask *diff.Mask) []diff.Hunk {
+	lines1 := diff.SplitLines(mask.Text(strings.TrimSpace(output1)))
+	lines2 := diff.SplitLines(mask.Text(strings.TrimSpace(output2)))
+	return diff.Hunks(diff.Lines(lines1, lines2), hunkContext)
+}
+
+// diffBody renders a compareDiff's hunks or JSON changes as plain text.
+func (d compareDiff) diffBody() string {
+	if len(d.Changes) > 0 {
+		lines := make([]string, len(d.Changes))
+		for i, change := range d.Changes {
+			lines[i] = change.String()
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+	return diff.Unified(d.Hunks)
+}
+
+func displayCompareJSON(report compareReport) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+type junitTestSuite struct {
+	XMLName    xml.Name        `xml:"testsuite"`
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+	SystemOut  *junitText      `xml:"system-out,omitempty"`
+	SystemErr  *junitText      `xml:"system-err,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+	SystemErr *junitText    `xml:"system-err,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// junitText keeps command output readable in CDATA instead of escaping
+// every newline.
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+func newJUnitText(s string) *junitText {
+	if s == "" {
+		return nil
+	}
+	return &junitText{Text: s}
+}
+
+// displayCompareJUnit writes one test case per version compared with the
+// baseline; a case fails when its output or exit code differs. The
+// baseline's own output goes to the suite's system-out and system-err.
+func displayCompareJUnit(report compareReport) error {
+	return writeJUnit(compareJUnitSuite(report, "jfvm compare: jf "+report.Command))
+}
+
+func compareJUnitSuite(report compareReport, name string) junitTestSuite {
+	base := report.Results[report.BaselineIndex]
+
+	suite := junitTestSuite{
+		Name: name,
+		Time: junitSeconds(base.DurationMs),
+		Properties: []junitProperty{
+			{Name: "command", Value: "jf " + report.Command},
+			{Name: "mode", Value: report.Mode},
+			{Name: "baseline", Value: report.Baseline},
+			{Name: "baseline.exit_code", Value: fmt.Sprint(base.ExitCode)},
+		},
+		SystemOut: newJUnitText(base.Stdout),
+		SystemErr: newJUnitText(base.Stderr),
+	}
+
+	addCase := func(testCase junitTestCase, reasons []string, body string) {
+		if len(reasons) > 0 {
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message: strings.Join(reasons, "; "),
+				Type:    "BehaviorChange",
+				Body:    body,
+			}
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	// With an expected exit code, or when it timed out, the baseline gets
+	// a case of its own
+	if report.ExpectedExitCode != nil || base.TimedOut {
+		var reasons []string
+		if base.TimedOut {
+			reasons = append(reasons, "timed out")
+		} else if base.UnexpectedExit {
+			reasons = append(reasons, fmt.Sprintf("exit code %d, expected %d", base.ExitCode, *report.ExpectedExitCode))
+		}
+		addCase(junitTestCase{
+			Name:      base.label() + " (baseline)",
+			ClassName: "jfvm.compare",
+			Time:      junitSeconds(base.DurationMs),
+		}, reasons, "")
+	}
+
+	for _, d := range report.Diffs {
+		result := report.Results[d.Index]
+		var reasons []string
+		if result.TimedOut {
+			reasons = append(reasons, "timed out")
+		} else if result.UnexpectedExit {
+			reasons = append(reasons, fmt.Sprintf("exit code %d, expected %d", result.ExitCode, *report.ExpectedExitCode))
+		}
+		if d.ExitCodeDiffers {
+			reasons = append(reasons, fmt.Sprintf("exit code %d → %d", base.ExitCode, result.ExitCode))
+		}
+		if d.OutputDiffers {
+			reasons = append(reasons, "output differs")
+		}
+		addCase(junitTestCase{
+			Name:      fmt.Sprintf("%s vs %s", result.label(), base.label()),
+			ClassName: "jfvm.compare",
+			Time:      junitSeconds(result.DurationMs),
+			SystemOut: newJUnitText(result.Stdout),
+			SystemErr: newJUnitText(result.Stderr),
+		}, reasons, d.diffBody())
+	}
+	return suite
+}
+
+func writeJUnit(v any) error {
+	fmt.Print(xml.Header)
+	encoder := xml.NewEncoder(os.Stdout)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
+
+func junitSeconds(ms float64) string {
+	return fmt.Sprintf("%.3f", ms/1000)
+}
+
+// displayCompareMarkdown writes a summary table, the diffs against the
+// baseline and every version's output, for PR comments and job summaries.
+func displayCompareMarkdown(report compareReport) {
+	fmt.Printf("## jfvm compare: `jf %s`\n\n", report.Command)
+	writeMarkdownComparison(report, "###")
+
+	for _, result := range report.Results {
+		fmt.Printf("<details><summary>Output of %s</summary>\n\n", result.Version)
+		fmt.Printf("```text\n%s\n```\n", strings.TrimSpace(result.Stdout))
+		if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+			fmt.Printf("\nstderr:\n\n```text\n%s\n```\n", stderr)
+		}
+		fmt.Printf("\n</details>\n\n")
+	}
+}
+
+// writeMarkdownComparison writes the version table, equivalence classes
+// and diffs, with diff headings at the given level.
+func writeMarkdownComparison(report compareReport, heading string) {
+	status := make([]string, len(report.Results))
+	status[report.BaselineIndex] = "baseline"
+	for _, d := range report.Diffs {
+		var parts []string
+		if d.ExitCodeDiffers {
+			parts = append(parts, "exit code")
+		}
+		if d.OutputDiffers {
+			parts = append(parts, "output")
+		}
+		status[d.Index] = "✅ same"
+		if len(parts) > 0 {
+			status[d.Index] = "❌ " + strings.Join(parts, ", ") + " differ"
+		}
+	}
+
+	fmt.Printf("| Version | Requested | Exit code | Duration | vs baseline |\n")
+	fmt.Printf("|---|---|---|---|---|\n")
+	for i, result := range report.Results {
+		exit := fmt.Sprint(result.ExitCode)
+		if result.UnexpectedExit {
+			exit += fmt.Sprintf(" ⚠️ expected %d", *report.ExpectedExitCode)
+		}
+		fmt.Printf("| %s | %s | %s | %.1f ms | %s |\n", result.Version, result.Requested, exit, result.DurationMs, status[i])
+	}
+	fmt.Printf("\n")
+
+	if len(report.Classes) > 1 {
+		fmt.Printf("**Equivalence classes:**\n\n")
+		for i, class := range report.Classes {
+			fmt.Printf("- %s: %s\n", classLabel(i), strings.Join(class, ", "))
+		}
+		fmt.Printf("\n")
+	}
+
+	for _, d := range report.Diffs {
+		if !d.OutputDiffers {
+			continue
+		}
+		fmt.Printf("%s %s vs %s\n\n", heading, report.Results[d.Index].label(), report.Results[report.BaselineIndex].label())
+		fence := "diff"
+		if len(d.Changes) > 0 {
+			fence = "text"
+		}
+		fmt.Printf("```%s\n%s```\n\n", fence, d.diffBody())
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestCompareJUnitSuiteKeepsDuplicateVersionsApart(t *testing.T) {
+	// latest resolved to 2.74.0, which is also compared (and the baseline)
+	// by number
+	requested := []string{"latest", "2.74.0", "2.72.0"}
+	results := []ExecutionResult{
+		{Version: "2.74.0", Output: "flaky\n", ExitCode: 1},
+		{Version: "2.74.0", Output: "ok\n"},
+		{Version: "2.72.0", Output: "ok\n"},
+	}
+	report := buildCompareReport(requested, results, 1, []string{"rt", "ping"}, diffOptions{})
+	suite := compareJUnitSuite(report, "test")
+
+	if suite.Tests != 2 || suite.Failures != 1 {
+		t.Fatalf("tests = %d, failures = %d; want 2 and 1", suite.Tests, suite.Failures)
+	}
+	want := []struct {
+		name   string
+		failed bool
+		stdout string
+	}{
+		{"2.74.0 (latest) vs 2.74.0", true, "flaky\n"},
+		{"2.72.0 vs 2.74.0", false, "ok\n"},
+	}
+	for i, w := range want {
+		tc := suite.TestCases[i]
+		if tc.Name != w.name || (tc.Failure != nil) != w.failed {
+			t.Errorf("case %d = %q (failed %v), want %q (failed %v)", i, tc.Name, tc.Failure != nil, w.name, w.failed)
+		}
+		if tc.SystemOut == nil || tc.SystemOut.Text != w.stdout {
+			t.Errorf("case %d stdout = %+v, want %q", i, tc.SystemOut, w.stdout)
+		}
+	}
+	if msg := suite.TestCases[0].Failure.Message; msg != "exit code 0 → 1; output differs" {
+		t.Errorf("failure message = %q", msg)
+	}
+	if suite.SystemOut == nil || suite.SystemOut.Text != "ok\n" {
+		t.Errorf("baseline stdout = %+v, want the baseline's own output", suite.SystemOut)
+	}
+}
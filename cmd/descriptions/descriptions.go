@@ -44,12 +44,20 @@ var Install = CommandDescription{
 			Command:     "jfvm install latest",
 			Description: "Install the latest available version",
 		},
+		{
+			Command:     "jfvm install 2.74",
+			Description: "Install the newest 2.74.x release",
+		},
+		{
+			Command:     "jfvm install \"^2.74.0\"",
+			Description: "Install the newest release compatible with 2.74.0",
+		},
 	},
 }
 
 var Use = CommandDescription{
 	Usage:       "Set a specific JFrog CLI version as active",
-	Description: "Activates the given version or alias. If .jfrog-version exists in the current directory, that will be used if no argument is passed.",
+	Description: "Activates the given version, alias, keyword (latest), channel or semver range. If no argument is passed, the nearest .jfrog-version in the current directory or its parents is used.",
 	Examples: []Example{
 		{
 			Command:     "jfvm use 2.74.0",
@@ -59,6 +67,10 @@ var Use = CommandDescription{
 			Command:     "jfvm use prod",
 			Description: "Switch to the version aliased as 'prod'",
 		},
+		{
+			Command:     "jfvm use \">=2.70 <2.75\"",
+			Description: "Switch to the newest installed (or released) version in a range",
+		},
 		{
 			Command:     "jfvm use",
 			Description: "Use version from .jfrog-version file",
@@ -66,6 +78,25 @@ var Use = CommandDescription{
 	},
 }
 
+var Shell = CommandDescription{
+	Usage:       "Use a JFrog CLI version in the current shell only",
+	Description: "Prints a shell command that sets JFVM_VERSION for the current session. The shim gives JFVM_VERSION precedence over .jfrog-version and the global version, so other terminals and background jobs are unaffected.",
+	Examples: []Example{
+		{
+			Command:     "eval \"$(jfvm shell 2.74.0)\"",
+			Description: "Use 2.74.0 in this bash/zsh session",
+		},
+		{
+			Command:     "jfvm shell prod --shell fish | source",
+			Description: "Use the 'prod' alias in this fish session",
+		},
+		{
+			Command:     "eval \"$(jfvm shell --unset)\"",
+			Description: "Go back to the global version",
+		},
+	},
+}
+
 var List = CommandDescription{
 	Usage:       "List all installed JFrog CLI versions",
 	Description: "Shows all installed versions and highlights the currently active one.",
@@ -77,6 +108,25 @@ var List = CommandDescription{
 	},
 }
 
+var LsRemote = CommandDescription{
+	Usage:       "List JFrog CLI versions available for install",
+	Description: "Queries the release repository (or configured mirror) for available versions, newest first. Installed versions are marked. The listing is cached for an hour; use --refresh to bypass the cache.",
+	Examples: []Example{
+		{
+			Command:     "jfvm ls-remote",
+			Description: "Show all available versions",
+		},
+		{
+			Command:     "jfvm ls-remote --prefix 2.74 --latest 5",
+			Description: "Show the five newest 2.74.x releases",
+		},
+		{
+			Command:     "jfvm ls-remote --refresh",
+			Description: "Re-query the release repository",
+		},
+	},
+}
+
 var Remove = CommandDescription{
 	Usage:       "Remove a specific JFrog CLI version",
 	Description: "Removes a specific version of JFrog CLI from your system.",
@@ -139,7 +189,7 @@ var Link = CommandDescription{
 
 var Compare = CommandDescription{
 	Usage:       "Compare JFrog CLI command output between versions",
-	Description: "Compare JFrog CLI command output between two versions in parallel with git-like diff visualization. Measures execution time, success rate, and highlights differences.",
+	Description: "Compare JFrog CLI command output between two or more versions in parallel with git-like diff visualization. Measures execution time, success rate, and highlights differences; with more than two versions, groups them by identical behavior and diffs each group against a baseline.",
 	Examples: []Example{
 		{
 			Command:     "jfvm compare 2.74.0 2.73.0 -- --version",
@@ -149,6 +199,10 @@ var Compare = CommandDescription{
 			Command:     "jfvm compare prod dev -- rt ping",
 			Description: "Compare command outputs using aliases",
 		},
+		{
+			Command:     "jfvm compare --baseline 2.74.0 2.70.0,2.72.0,2.74.0,latest -- rt ping",
+			Description: "Group several versions by behavior and diff against a baseline",
+		},
 		{
 			Command:     "jfvm compare 2.74.0 2.73.0 -- config show --unified",
 			Description: "Show unified diff format",
@@ -157,12 +211,36 @@ var Compare = CommandDescription{
 			Command:     "jfvm compare old new -- rt search \"*.jar\" --no-color --timing=false",
 			Description: "Disable colored output and timing",
 		},
+		{
+			Command:     "jfvm compare --mode json --ignore-path '$..created' 2.74.0 2.75.0 -- rt search \"*.jar\"",
+			Description: "Compare JSON output by path, ignoring a volatile field",
+		},
+		{
+			Command:     "jfvm compare --format junit --fail-on-diff --fail-on-exit-diff 2.74.0 2.75.0 -- rt ping",
+			Description: "Emit a JUnit report and fail when behavior changes",
+		},
+		{
+			Command:     "jfvm compare --format markdown 2.74.0 2.75.0 -- config show",
+			Description: "Write a Markdown summary with diffs and outputs",
+		},
+		{
+			Command:     "jfvm compare --scenario suite.yaml --show-diffs 2.74.0 2.75.0",
+			Description: "Compare every command in a scenario file",
+		},
+		{
+			Command:     "jfvm compare --isolate 2.74.0 2.75.0 -- config show",
+			Description: "Give each version its own copy of your JFrog CLI config",
+		},
+		{
+			Command:     "jfvm compare --ignore-regex '\\d+ms' old new -- rt ping",
+			Description: "Mask durations before diffing text output",
+		},
 	},
 }
 
 var Benchmark = CommandDescription{
 	Usage:       "Benchmark JFrog CLI command performance across versions",
-	Description: "Run performance benchmarks for JFrog CLI commands across multiple versions. Measures execution time, success rate, and provides statistical analysis.",
+	Description: "Run performance benchmarks for JFrog CLI commands across multiple versions. Measures execution time and success rate, reports mean ± standard deviation, median and confidence intervals with outliers removed, and only declares a winner when a significance test says the difference is real.",
 	Examples: []Example{
 		{
 			Command:     "jfvm benchmark 2.74.0,2.73.0,2.72.0 -- --version",
@@ -180,12 +258,24 @@ var Benchmark = CommandDescription{
 			Command:     "jfvm benchmark 2.74.0,2.73.0 -- rt search \"*.jar\" --format csv",
 			Description: "Export results as CSV",
 		},
+		{
+			Command:     "jfvm benchmark --scenario suite.yaml 2.74.0,2.75.0",
+			Description: "Benchmark every command in a scenario file",
+		},
+		{
+			Command:     "jfvm benchmark --warmup 2 --iterations 20 --test welch 2.74.0,2.75.0 -- rt ping",
+			Description: "Warm up, then compare versions with Welch's t-test",
+		},
+		{
+			Command:     "jfvm benchmark --isolate --isolate-workdir 2.74.0,2.75.0 -- rt download \"libs/*.jar\"",
+			Description: "Benchmark with a private config and working directory per version",
+		},
 	},
 }
 
 var History = CommandDescription{
 	Usage:       "Show version usage history and statistics",
-	Description: "Display historical usage patterns for JFrog CLI versions. Tracks when versions were used, most common commands, usage trends, and command outputs.",
+	Description: "Display historical usage patterns for JFrog CLI versions. Tracks when versions were used, most common commands, latency percentiles, failure rates, usage trends, regressions after version switches, and command outputs. Output is only recorded for streams that were not a terminal (piped, redirected or in CI); interactive runs record the command, timing and exit code only, so replays of them compare exit codes.",
 	Examples: []Example{
 		{
 			Command:     "jfvm history",
@@ -197,7 +287,11 @@ var History = CommandDescription{
 		},
 		{
 			Command:     "jfvm history --stats",
-			Description: "Show detailed statistics",
+			Description: "Show latency percentiles, failure rates, weekly trends and regressions",
+		},
+		{
+			Command:     "jfvm history --stats --format json",
+			Description: "Statistics as JSON",
 		},
 		{
 			Command:     "jfvm history --version 2.74.0",
@@ -205,15 +299,47 @@ var History = CommandDescription{
 		},
 		{
 			Command:     "jfvm history --show-output",
-			Description: "Show captured command outputs",
+			Description: "Show captured command outputs (not recorded for output shown in a terminal)",
 		},
 		{
 			Command:     "jfvm history --format json",
 			Description: "Export as JSON",
 		},
+		{
+			Command:     "jfvm history --format csv --limit 0",
+			Description: "Export all entries as CSV",
+		},
+		{
+			Command:     "jfvm history export --endpoint http://localhost:4318",
+			Description: "Send entries as OpenTelemetry spans to an OTLP collector",
+		},
+		{
+			Command:     "jfvm history --since 7d --exit-code 1",
+			Description: "Show failures from the last 7 days",
+		},
+		{
+			Command:     "jfvm history --show-context",
+			Description: "Show where each run's version came from",
+		},
+		{
+			Command:     "jfvm history --where 'version=2.74.* and duration>5s'",
+			Description: "Filter with a query expression",
+		},
+		{
+			Command:     "jfvm history replay 3f9a2c1b --version 2.75.0",
+			Description: "Re-run a recorded command with another version and diff the results",
+		},
 		{
 			Command:     "jfvm history --clear",
 			Description: "Clear history (cannot be undone)",
 		},
+		{
+			Command:     "jfvm history prune --older-than 30d",
+			Description: "Remove entries older than 30 days",
+		},
+		{
+			Command:     "jfvm history prune --version 2.70.0 --failures-only --dry-run",
+			Description: "Preview removing failed runs of one version",
+		},
 	},
 }
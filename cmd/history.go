@@ -1,44 +1,32 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bhanurp/jfvm/cmd/descriptions"
-	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal/history"
+	"github.com/bhanurp/jfvm/internal/stats"
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 )
 
-type HistoryEntry struct {
-	Version   string    `json:"version"`
-	Timestamp time.Time `json:"timestamp"`
-	Command   string    `json:"command,omitempty"`
-	Duration  int64     `json:"duration_ms,omitempty"`
-	ExitCode  int       `json:"exit_code,omitempty"`
-	Stdout    string    `json:"stdout,omitempty"`
-	Stderr    string    `json:"stderr,omitempty"`
-}
-
-type VersionStats struct {
-	Version   string
-	Count     int
-	FirstUsed time.Time
-	LastUsed  time.Time
-	TotalTime time.Duration
-	Commands  map[string]int
-}
-
 var History = &cli.Command{
 	Name:        "history",
 	Usage:       descriptions.History.Usage,
 	Description: descriptions.History.Format(),
-	Flags: []cli.Flag{
+	Subcommands: []*cli.Command{historyPrune, historyExport, historyReplay},
+	Flags: append([]cli.Flag{
 		&cli.IntFlag{
 			Name:  "limit",
 			Usage: "Limit number of entries to show",
@@ -49,10 +37,6 @@ var History = &cli.Command{
 			Usage: "Show aggregated statistics",
 			Value: false,
 		},
-		&cli.StringFlag{
-			Name:  "version",
-			Usage: "Filter by specific version",
-		},
 		&cli.BoolFlag{
 			Name:  "no-color",
 			Usage: "Disable colored output",
@@ -60,7 +44,7 @@ var History = &cli.Command{
 		},
 		&cli.StringFlag{
 			Name:  "format",
-			Usage: "Output format: table, json",
+			Usage: "Output format: table, json, ndjson, csv",
 			Value: "table",
 		},
 		&cli.BoolFlag{
@@ -70,145 +54,182 @@ var History = &cli.Command{
 		},
 		&cli.BoolFlag{
 			Name:  "show-output",
-			Usage: "Show command output in history entries",
+			Usage: "Show command output in history entries (only recorded when jf's output was not a terminal)",
 			Value: false,
 		},
-		&cli.StringFlag{
-			Name:  "command",
-			Usage: "Filter by command pattern (case-insensitive)",
-		},
 		&cli.BoolFlag{
-			Name:  "failures-only",
-			Usage: "Show only failed commands (exit code != 0)",
-			Value: false,
+			Name:  "show-context",
+			Usage: "Show working directory, version source, host, server ID and JFROG_CLI_* variables",
 		},
-	},
+	}, historyFilterFlags()...),
 	Action: func(c *cli.Context) error {
 		if c.Bool("clear") {
 			return clearHistory()
 		}
 
-		historyFile := filepath.Join(utils.JfvmRoot, "history.json")
+		filter, err := historyFilter(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 
-		entries, err := loadHistory(historyFile)
-		if err != nil && !os.IsNotExist(err) {
+		entries, err := history.Load()
+		if err != nil {
 			return fmt.Errorf("failed to load history: %w", err)
 		}
 
-		// Filter by version if specified
-		if version := c.String("version"); version != "" {
-			filtered := []HistoryEntry{}
-			for _, entry := range entries {
-				if entry.Version == version {
-					filtered = append(filtered, entry)
-				}
-			}
-			entries = filtered
-		}
+		entries = history.Query(entries, filter)
 
-		// Filter by command pattern if specified
-		if cmdPattern := c.String("command"); cmdPattern != "" {
-			filtered := []HistoryEntry{}
-			for _, entry := range entries {
-				if strings.Contains(strings.ToLower(entry.Command), strings.ToLower(cmdPattern)) {
-					filtered = append(filtered, entry)
-				}
-			}
-			entries = filtered
+		format := c.String("format")
+		switch format {
+		case "table", "json", "ndjson", "csv":
+		default:
+			return cli.Exit(fmt.Sprintf("Unknown format %q: use table, json, ndjson or csv", format), 1)
 		}
 
-		// Filter failures only if specified
-		if c.Bool("failures-only") {
-			filtered := []HistoryEntry{}
-			for _, entry := range entries {
-				if entry.ExitCode != 0 {
-					filtered = append(filtered, entry)
-				}
-			}
-			entries = filtered
-		}
-
-		if len(entries) == 0 {
+		// Machine-readable formats stay parseable when nothing matches
+		if len(entries) == 0 && format == "table" {
 			fmt.Println("📭 No history entries found.")
 			return nil
 		}
 
 		if c.Bool("stats") {
-			displayHistoryStats(entries, c.Bool("no-color"))
+			switch format {
+			case "json":
+				displayHistoryStatsJSON(history.Analyze(entries))
+			case "table":
+				displayHistoryStats(history.Analyze(entries), c.Bool("no-color"))
+			default:
+				return cli.Exit("--stats supports --format table or json", 1)
+			}
 		} else {
-			displayHistory(entries, c.Int("limit"), c.String("format"), c.Bool("no-color"), c.Bool("show-output"))
+			displayHistory(entries, c.Int("limit"), format, c.Bool("no-color"), c.Bool("show-output"), c.Bool("show-context"))
 		}
 
 		return nil
 	},
 }
 
-func loadHistory(historyFile string) ([]HistoryEntry, error) {
-	data, err := os.ReadFile(historyFile)
-	if err != nil {
-		return nil, err
-	}
-
-	var entries []HistoryEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return nil, err
+// historyFilterFlags returns the flags read by historyFilter, shared by
+// `jfvm history` and `jfvm history export`.
+func historyFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "version",
+			Usage: "Filter by specific version",
+		},
+		&cli.StringFlag{
+			Name:  "command",
+			Usage: "Filter by command pattern (case-insensitive)",
+		},
+		&cli.BoolFlag{
+			Name:  "failures-only",
+			Usage: "Show only failed commands (exit code != 0)",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  "command-regex",
+			Usage: "Filter by command regular expression",
+		},
+		&cli.IntFlag{
+			Name:  "exit-code",
+			Usage: "Filter by exact exit code",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Show entries at or after a time (2006-01-02, RFC 3339) or duration ago (2h, 7d)",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "Show entries at or before a time (2006-01-02, RFC 3339) or duration ago (2h, 7d)",
+		},
+		&cli.StringFlag{
+			Name:  "min-duration",
+			Usage: "Show entries that took at least this long (e.g. 500ms, 5s)",
+		},
+		&cli.StringFlag{
+			Name:  "cwd",
+			Usage: "Show entries run in a directory or below it",
+		},
+		&cli.StringFlag{
+			Name:  "source",
+			Usage: "Filter by what selected the version (JFVM_VERSION, a .jfrog-version path, config)",
+		},
+		&cli.StringFlag{
+			Name:  "host",
+			Usage: "Filter by hostname",
+		},
+		&cli.StringFlag{
+			Name:  "server-id",
+			Usage: "Filter by JFrog server ID",
+		},
+		&cli.StringFlag{
+			Name:    "where",
+			Aliases: []string{"q"},
+			Usage:   "Filter with a query expression (e.g. 'version=2.74.* and duration>5s')",
+		},
 	}
-
-	return entries, nil
 }
 
-func saveHistory(historyFile string, entries []HistoryEntry) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
-		return err
+// historyFilter builds the entry filter from the history command's flags.
+func historyFilter(c *cli.Context) (history.Filter, error) {
+	filter := history.Filter{
+		Version:      c.String("version"),
+		Command:      c.String("command"),
+		FailuresOnly: c.Bool("failures-only"),
+		Source:       c.String("source"),
+		Hostname:     c.String("host"),
+		ServerID:     c.String("server-id"),
 	}
 
-	data, err := json.MarshalIndent(entries, "", "  ")
-	if err != nil {
-		return err
+	if pattern := c.String("command-regex"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --command-regex: %w", err)
+		}
+		filter.CommandRegex = re
 	}
-
-	return os.WriteFile(historyFile, data, 0644)
-}
-
-func AddHistoryEntry(version, command string, duration time.Duration, exitCode int, stdout, stderr string) {
-	historyFile := filepath.Join(utils.JfvmRoot, "history.json")
-
-	entries, err := loadHistory(historyFile)
-	if err != nil && !os.IsNotExist(err) {
-		return
+	if c.IsSet("exit-code") {
+		code := c.Int("exit-code")
+		filter.ExitCode = &code
 	}
 
-	// Truncate output to prevent huge history files
-	const maxOutputSize = 5000
-	if len(stdout) > maxOutputSize {
-		stdout = stdout[:maxOutputSize] + "\n... (truncated)"
-	}
-	if len(stderr) > maxOutputSize {
-		stderr = stderr[:maxOutputSize] + "\n... (truncated)"
+	now := time.Now()
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.String(name); value != "" {
+			t, err := history.ParseTime(value, now)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --%s: %w", name, err)
+			}
+			*target = t
+		}
 	}
 
-	entry := HistoryEntry{
-		Version:   version,
-		Timestamp: time.Now(),
-		Command:   command,
-		Duration:  duration.Milliseconds(),
-		ExitCode:  exitCode,
-		Stdout:    stdout,
-		Stderr:    stderr,
+	if value := c.String("min-duration"); value != "" {
+		d, err := history.ParseDuration(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --min-duration: %w", err)
+		}
+		filter.MinDuration = d
 	}
-
-	entries = append(entries, entry)
-
-	// Keep only last 1000 entries to prevent unlimited growth
-	if len(entries) > 1000 {
-		entries = entries[len(entries)-1000:]
+	if dir := c.String("cwd"); dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --cwd: %w", err)
+		}
+		filter.Cwd = abs
+	}
+	if query := c.String("where"); query != "" {
+		expr, err := history.ParseExpr(query)
+		if err != nil {
+			return filter, err
+		}
+		filter.Where = expr
 	}
 
-	saveHistory(historyFile, entries)
+	return filter, nil
 }
 
-func displayHistory(entries []HistoryEntry, limit int, format string, noColor, showOutput bool) {
+func displayHistory(entries []history.Entry, limit int, format string, noColor, showOutput, showContext bool) {
 	if noColor {
 		color.NoColor = true
 	}
@@ -225,12 +246,16 @@ func displayHistory(entries []HistoryEntry, limit int, format string, noColor, s
 	switch format {
 	case "json":
 		displayHistoryJSON(entries)
+	case "ndjson":
+		displayHistoryNDJSON(os.Stdout, entries, showOutput)
+	case "csv":
+		displayHistoryCSV(os.Stdout, entries, showOutput)
 	default:
-		displayHistoryTable(entries, showOutput)
+		displayHistoryTable(entries, showOutput, showContext)
 	}
 }
 
-func displayHistoryTable(entries []HistoryEntry, showOutput bool) {
+func displayHistoryTable(entries []history.Entry, showOutput, showContext bool) {
 	var (
 		blueColor   = color.New(color.FgBlue)
 		greenColor  = color.New(color.FgGreen)
@@ -242,9 +267,9 @@ func displayHistoryTable(entries []HistoryEntry, showOutput bool) {
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n\n")
 
 	if showOutput {
-		fmt.Printf("%-20s %-15s %-12s %-8s %-30s\n", "TIMESTAMP", "VERSION", "DURATION", "EXIT", "COMMAND")
+		fmt.Printf("%-9s %-20s %-15s %-12s %-8s %-30s\n", "ID", "TIMESTAMP", "VERSION", "DURATION", "EXIT", "COMMAND")
 	} else {
-		fmt.Printf("%-20s %-15s %-12s %-30s\n", "TIMESTAMP", "VERSION", "DURATION", "COMMAND")
+		fmt.Printf("%-9s %-20s %-15s %-12s %-30s\n", "ID", "TIMESTAMP", "VERSION", "DURATION", "COMMAND")
 	}
 	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
@@ -273,12 +298,16 @@ func displayHistoryTable(entries []HistoryEntry, showOutput bool) {
 		}
 
 		if showOutput {
-			fmt.Printf("%-20s %-15s %-12s %-8s %-30s\n",
+			fmt.Printf("%-9s %-20s %-15s %-12s %-8s %-30s\n",
+				shortID(entry.ID),
 				blueColor.Sprint(timestamp),
 				greenColor.Sprint(entry.Version),
 				durationColor.Sprint(duration),
 				exitCodeColor.Sprint(exitCodeText),
 				command)
+			if showContext {
+				displayEntryContext(entry)
+			}
 
 			if entry.Stdout != "" {
 				fmt.Printf("  📤 STDOUT:\n%s\n", entry.Stdout)
@@ -286,22 +315,52 @@ func displayHistoryTable(entries []HistoryEntry, showOutput bool) {
 			if entry.Stderr != "" {
 				fmt.Printf("  📥 STDERR:\n%s\n", redColor.Sprint(entry.Stderr))
 			}
+			if !entry.StdoutCaptured && !entry.StderrCaptured {
+				fmt.Printf("  %s\n", yellowColor.Sprint("(output not recorded)"))
+			}
 			if entry.Stdout != "" || entry.Stderr != "" {
 				fmt.Println()
 			}
 		} else {
-			fmt.Printf("%-20s %-15s %-12s %-30s\n",
+			fmt.Printf("%-9s %-20s %-15s %-12s %-30s\n",
+				shortID(entry.ID),
 				blueColor.Sprint(timestamp),
 				greenColor.Sprint(entry.Version),
 				durationColor.Sprint(duration),
 				command)
+			if showContext {
+				displayEntryContext(entry)
+			}
 		}
 	}
 
 	fmt.Printf("\n📈 Total entries: %d\n", len(entries))
 }
 
-func displayHistoryJSON(entries []HistoryEntry) {
+// displayEntryContext prints the recorded context lines under a table row.
+// Entries recorded by older versions have none.
+func displayEntryContext(entry history.Entry) {
+	dimColor := color.New(color.Faint)
+
+	source := entry.Source
+	if entry.Spec != "" {
+		source = fmt.Sprintf("%s (%s)", source, entry.Spec)
+	}
+	fields := []struct{ label, value string }{
+		{"cwd", entry.Cwd},
+		{"source", source},
+		{"host", entry.Hostname},
+		{"server", entry.ServerID},
+		{"env", strings.Join(entry.Env, ", ")},
+	}
+	for _, field := range fields {
+		if strings.TrimSpace(field.value) != "" {
+			fmt.Printf("  %s %s\n", dimColor.Sprintf("%-7s", field.label+":"), field.value)
+		}
+	}
+}
+
+func displayHistoryJSON(entries []history.Entry) {
 	data, err := json.MarshalIndent(entries, "", "  ")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
@@ -310,7 +369,56 @@ func displayHistoryJSON(entries []HistoryEntry) {
 	fmt.Println(string(data))
 }
 
-func displayHistoryStats(entries []HistoryEntry, noColor bool) {
+// displayHistoryNDJSON prints one entry per line for log shippers. Output is
+// only included with --show-output to keep lines small.
+func displayHistoryNDJSON(out io.Writer, entries []history.Entry, showOutput bool) {
+	encoder := json.NewEncoder(out)
+	for _, entry := range entries {
+		if !showOutput {
+			entry.Stdout, entry.Stderr = "", ""
+		}
+		if err := encoder.Encode(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+			return
+		}
+	}
+}
+
+func displayHistoryCSV(out io.Writer, entries []history.Entry, showOutput bool) {
+	header := []string{"id", "timestamp", "version", "command", "duration_ms", "exit_code", "cwd", "source", "spec", "hostname", "server_id", "env"}
+	if showOutput {
+		header = append(header, "stdout", "stderr")
+	}
+
+	w := csv.NewWriter(out)
+	_ = w.Write(header)
+	for _, entry := range entries {
+		record := []string{
+			entry.ID,
+			entry.Timestamp.Format(time.RFC3339),
+			entry.Version,
+			entry.Command,
+			strconv.FormatInt(entry.Duration, 10),
+			strconv.Itoa(entry.ExitCode),
+			entry.Cwd,
+			entry.Source,
+			entry.Spec,
+			entry.Hostname,
+			entry.ServerID,
+			strings.Join(entry.Env, ";"),
+		}
+		if showOutput {
+			record = append(record, entry.Stdout, entry.Stderr)
+		}
+		_ = w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+	}
+}
+
+func displayHistoryStats(report history.Report, noColor bool) {
 	if noColor {
 		color.NoColor = true
 	}
@@ -319,145 +427,362 @@ func displayHistoryStats(entries []HistoryEntry, noColor bool) {
 		greenColor  = color.New(color.FgGreen, color.Bold)
 		blueColor   = color.New(color.FgBlue, color.Bold)
 		yellowColor = color.New(color.FgYellow, color.Bold)
+		redColor    = color.New(color.FgRed, color.Bold)
 	)
 
-	// Aggregate statistics
-	stats := make(map[string]*VersionStats)
-	totalCommands := make(map[string]int)
-
-	for _, entry := range entries {
-		if stats[entry.Version] == nil {
-			stats[entry.Version] = &VersionStats{
-				Version:   entry.Version,
-				FirstUsed: entry.Timestamp,
-				LastUsed:  entry.Timestamp,
-				Commands:  make(map[string]int),
-			}
-		}
-
-		s := stats[entry.Version]
-		s.Count++
-		s.TotalTime += time.Duration(entry.Duration) * time.Millisecond
-
-		if entry.Timestamp.Before(s.FirstUsed) {
-			s.FirstUsed = entry.Timestamp
-		}
-		if entry.Timestamp.After(s.LastUsed) {
-			s.LastUsed = entry.Timestamp
-		}
-
-		if entry.Command != "" {
-			s.Commands[entry.Command]++
-			totalCommands[entry.Command]++
-		}
-	}
-
 	fmt.Printf("📊 JFVM USAGE STATISTICS\n")
 	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n\n")
 
-	// Version usage
+	// Version usage, most used first
 	fmt.Printf("🔢 VERSION USAGE:\n")
 	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
-
-	// Sort versions by usage count
-	versions := make([]*VersionStats, 0, len(stats))
-	for _, stat := range stats {
-		versions = append(versions, stat)
-	}
-	sort.Slice(versions, func(i, j int) bool {
-		return versions[i].Count > versions[j].Count
-	})
-
-	fmt.Printf("%-15s %-8s %-12s %-20s %-20s\n", "VERSION", "COUNT", "TOTAL TIME", "FIRST USED", "LAST USED")
+	fmt.Printf("%-15s %-8s %-7s %-12s %-9s %-9s %-9s %-17s %-17s\n", "VERSION", "COUNT", "FAIL%", "TOTAL TIME", "P50", "P90", "P99", "FIRST USED", "LAST USED")
 	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-	for i, stat := range versions {
+	for i, stat := range report.Versions {
 		var versionColor *color.Color = blueColor
 		if i == 0 {
 			versionColor = greenColor
 		}
 
-		fmt.Printf("%-15s %-8s %-12s %-20s %-20s\n",
+		fmt.Printf("%-15s %-8s %-7s %-12s %-9s %-9s %-9s %-17s %-17s\n",
 			versionColor.Sprint(stat.Version),
 			yellowColor.Sprintf("%d", stat.Count),
-			formatDuration(stat.TotalTime),
+			failureRateColor(stat.FailureRate, greenColor, redColor).Sprint(formatRate(stat.FailureRate)),
+			formatDuration(time.Duration(stat.TotalTimeMs)*time.Millisecond),
+			formatMillis(stat.Latency.P50),
+			formatMillis(stat.Latency.P90),
+			formatMillis(stat.Latency.P99),
 			stat.FirstUsed.Format("2006-01-02 15:04"),
 			stat.LastUsed.Format("2006-01-02 15:04"))
 	}
 
-	// Most common commands
-	if len(totalCommands) > 0 {
-		fmt.Printf("\n🚀 MOST COMMON COMMANDS:\n")
+	// Latency per command and version
+	if len(report.Commands) > 0 {
+		fmt.Printf("\n⏱️  LATENCY BY COMMAND:\n")
+		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+		fmt.Printf("%-22s %-15s %-8s %-7s %-9s %-9s %-9s\n", "COMMAND", "VERSION", "COUNT", "FAIL%", "P50", "P90", "P99")
 		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-		// Sort commands by frequency
-		type commandStat struct {
-			command string
-			count   int
+		maxShow := min(len(report.Commands), 15)
+		for _, stat := range report.Commands[:maxShow] {
+			command := stat.Command
+			if command == "" {
+				command = "(none)"
+			}
+			fmt.Printf("%-22s %-15s %-8d %-7s %-9s %-9s %-9s\n",
+				command,
+				blueColor.Sprint(stat.Version),
+				stat.Count,
+				failureRateColor(stat.FailureRate, greenColor, redColor).Sprint(formatRate(stat.FailureRate)),
+				formatMillis(stat.Latency.P50),
+				formatMillis(stat.Latency.P90),
+				formatMillis(stat.Latency.P99))
 		}
-		commands := make([]commandStat, 0, len(totalCommands))
-		for cmd, count := range totalCommands {
-			commands = append(commands, commandStat{cmd, count})
+		if len(report.Commands) > maxShow {
+			fmt.Printf("... %d more (use --format json for all)\n", len(report.Commands)-maxShow)
 		}
-		sort.Slice(commands, func(i, j int) bool {
-			return commands[i].count > commands[j].count
-		})
+	}
 
-		maxShow := 10
-		if len(commands) < maxShow {
-			maxShow = len(commands)
-		}
+	// Most common commands
+	if len(report.TopCommands) > 0 {
+		fmt.Printf("\n🚀 MOST COMMON COMMANDS:\n")
+		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-		for i, cmd := range commands[:maxShow] {
+		for i, cmd := range report.TopCommands {
 			var color *color.Color = blueColor
 			if i == 0 {
 				color = greenColor
 			}
-			fmt.Printf("%-50s %s\n", cmd.command, color.Sprintf("(%d times)", cmd.count))
+			fmt.Printf("%-50s %s\n", cmd.Command, color.Sprintf("(%d times)", cmd.Count))
 		}
 	}
 
-	// Timeline
-	fmt.Printf("\n📅 USAGE TIMELINE:\n")
-	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+	// Week-over-week trends, most recent weeks
+	if len(report.Weeks) > 0 {
+		fmt.Printf("\n📈 WEEKLY TRENDS:\n")
+		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+		fmt.Printf("%-10s %-12s %-8s %-9s %-7s %-9s %-9s %-9s\n", "WEEK", "STARTING", "COUNT", "Δ COUNT", "FAIL%", "Δ FAIL", "MEDIAN", "Δ MEDIAN")
+		fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
 
-	if len(entries) > 0 {
-		oldest := entries[0].Timestamp
-		newest := entries[0].Timestamp
-		for _, entry := range entries {
-			if entry.Timestamp.Before(oldest) {
-				oldest = entry.Timestamp
-			}
-			if entry.Timestamp.After(newest) {
-				newest = entry.Timestamp
+		weeks := report.Weeks
+		if len(weeks) > 8 {
+			weeks = weeks[len(weeks)-8:]
+		}
+		for i, week := range weeks {
+			countChange, failChange, medianChange := "", "", ""
+			if i > 0 || len(weeks) < len(report.Weeks) {
+				countChange = fmt.Sprintf("%+.0f%%", week.CountChange*100)
+				failChange = fmt.Sprintf("%+.0f pts", week.FailureRateChange*100)
+				medianChange = fmt.Sprintf("%+.0f%%", week.MedianChange*100)
 			}
+			fmt.Printf("%-10s %-12s %-8d %-9s %-7s %-9s %-9s %-9s\n",
+				week.Week,
+				week.Start,
+				week.Count,
+				countChange,
+				failureRateColor(week.FailureRate, greenColor, redColor).Sprint(formatRate(week.FailureRate)),
+				failChange,
+				formatMillis(week.MedianMs),
+				medianChange)
 		}
+	}
 
-		duration := newest.Sub(oldest)
-		avgPerDay := float64(len(entries)) / (duration.Hours() / 24)
+	// Regressions after version switches
+	fmt.Printf("\n⚠️  REGRESSIONS AFTER VERSION SWITCHES:\n")
+	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+	if len(report.Regressions) == 0 {
+		fmt.Println(greenColor.Sprint("None detected"))
+	}
+	for _, r := range report.Regressions {
+		fmt.Printf("%s  %s → %s  %s\n",
+			redColor.Sprint(r.Command),
+			r.From,
+			r.To,
+			strings.Join(r.Reasons, ", "))
+		fmt.Printf("    median %s → %s, failures %s → %s (switched %s)\n",
+			formatMillis(r.MedianBeforeMs),
+			formatMillis(r.MedianAfterMs),
+			formatRate(r.FailureRateBefore),
+			formatRate(r.FailureRateAfter),
+			r.SwitchedAt.Format("2006-01-02"))
+	}
 
-		fmt.Printf("First usage: %s\n", greenColor.Sprint(oldest.Format("2006-01-02 15:04:05")))
-		fmt.Printf("Latest usage: %s\n", greenColor.Sprint(newest.Format("2006-01-02 15:04:05")))
+	// Timeline
+	fmt.Printf("\n📅 USAGE TIMELINE:\n")
+	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+
+	if report.Total > 0 {
+		duration := report.LastUsed.Sub(report.FirstUsed)
+		avgPerDay := float64(report.Total) / (duration.Hours() / 24)
+
+		fmt.Printf("First usage: %s\n", greenColor.Sprint(report.FirstUsed.Format("2006-01-02 15:04:05")))
+		fmt.Printf("Latest usage: %s\n", greenColor.Sprint(report.LastUsed.Format("2006-01-02 15:04:05")))
 		fmt.Printf("Total period: %s\n", yellowColor.Sprint(formatDuration(duration)))
-		fmt.Printf("Total entries: %s\n", yellowColor.Sprintf("%d", len(entries)))
+		fmt.Printf("Total entries: %s\n", yellowColor.Sprintf("%d", report.Total))
+		fmt.Printf("Failures: %s\n", yellowColor.Sprintf("%d (%s)", report.Failures, formatRate(stats.Ratio(report.Failures, report.Total))))
 		if duration.Hours() > 24 {
 			fmt.Printf("Average per day: %s\n", yellowColor.Sprintf("%.1f", avgPerDay))
 		}
 	}
 }
 
-func clearHistory() error {
-	historyFile := filepath.Join(utils.JfvmRoot, "history.json")
+func displayHistoryStatsJSON(report history.Report) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
 
-	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
+// formatMillis renders a millisecond figure; zero means no timed runs.
+func formatMillis(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return formatDuration(time.Duration(ms * float64(time.Millisecond)))
+}
+
+func failureRateColor(rate float64, ok, bad *color.Color) *color.Color {
+	if rate > 0 {
+		return bad
+	}
+	return ok
+}
+
+func clearHistory() error {
+	if !history.Exists(history.Dir()) {
 		fmt.Println("📭 No history file found.")
 		return nil
 	}
 
-	if err := os.Remove(historyFile); err != nil {
+	if err := history.Clear(history.Dir()); err != nil {
 		return fmt.Errorf("failed to clear history: %w", err)
 	}
 
 	fmt.Println("🗑️  History cleared successfully.")
 	return nil
 }
+
+var historyPrune = &cli.Command{
+	Name:  "prune",
+	Usage: "Remove history entries matching all given criteria",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "older-than",
+			Usage: "Remove entries older than a duration (e.g. 30d, 2w, 12h)",
+		},
+		&cli.StringFlag{
+			Name:  "version",
+			Usage: "Remove entries for a specific version",
+		},
+		&cli.StringFlag{
+			Name:  "command",
+			Usage: "Remove entries whose command matches a pattern (case-insensitive)",
+		},
+		&cli.BoolFlag{
+			Name:  "failures-only",
+			Usage: "Remove only failed commands (exit code != 0)",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Show what would be removed without changing history",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		filter := history.Filter{
+			Version:      c.String("version"),
+			Command:      c.String("command"),
+			FailuresOnly: c.Bool("failures-only"),
+		}
+
+		var cutoff time.Time
+		if value := c.String("older-than"); value != "" {
+			age, err := history.ParseDuration(value)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("Invalid --older-than: %v", err), 1)
+			}
+			cutoff = time.Now().Add(-age)
+		}
+
+		if cutoff.IsZero() && filter == (history.Filter{}) {
+			return cli.Exit("Specify at least one of --older-than, --version, --command or --failures-only (use 'jfvm history --clear' to remove everything)", 1)
+		}
+
+		dryRun := c.Bool("dry-run")
+		removed, err := history.Prune(func(e history.Entry) bool {
+			if !cutoff.IsZero() && !e.Timestamp.Before(cutoff) {
+				return false
+			}
+			return filter.Match(e)
+		}, dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to prune history: %w", err)
+		}
+
+		if len(removed) == 0 {
+			fmt.Println("📭 No matching history entries.")
+			return nil
+		}
+
+		if dryRun {
+			fmt.Printf("🔍 Would remove %d entries:\n", len(removed))
+		} else {
+			fmt.Printf("🗑️  Removed %d entries:\n", len(removed))
+		}
+
+		perVersion := make(map[string]int)
+		for _, e := range removed {
+			perVersion[e.Version]++
+		}
+		versions := make([]string, 0, len(perVersion))
+		for v := range perVersion {
+			versions = append(versions, v)
+		}
+		sort.Strings(versions)
+		for _, v := range versions {
+			fmt.Printf("  %-15s %d\n", v, perVersion[v])
+		}
+
+		return nil
+	},
+}
+
+var historyExport = &cli.Command{
+	Name:  "export",
+	Usage: "Export history as OpenTelemetry (OTLP/JSON) trace spans",
+	Description: "Turns each recorded jf invocation into one span with version, command and exit code attributes. " +
+		"Writes OTLP/JSON to a file (or stdout) or posts it to an OTLP HTTP collector.",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Write OTLP/JSON to a file (- for stdout)",
+		},
+		&cli.StringFlag{
+			Name:    "endpoint",
+			Usage:   "OTLP HTTP collector endpoint (/v1/traces is appended if missing)",
+			EnvVars: []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: "Extra HTTP header for the collector as key=value (repeatable); OTEL_EXPORTER_OTLP_HEADERS is also read",
+		},
+		&cli.StringFlag{
+			Name:    "service-name",
+			Usage:   "service.name resource attribute",
+			Value:   "jfvm",
+			EnvVars: []string{"OTEL_SERVICE_NAME"},
+		},
+	}, historyFilterFlags()...),
+	Action: func(c *cli.Context) error {
+		output, endpoint := c.String("output"), c.String("endpoint")
+		if output == "" && endpoint == "" {
+			return cli.Exit("Specify --output <file> or --endpoint <url>", 1)
+		}
+
+		filter, err := historyFilter(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		entries, err := history.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load history: %w", err)
+		}
+		entries = history.Query(entries, filter)
+
+		payload, err := history.OTLPSpans(entries, c.String("service-name"))
+		if err != nil {
+			return fmt.Errorf("failed to encode spans: %w", err)
+		}
+
+		switch output {
+		case "":
+		case "-":
+			fmt.Println(string(payload))
+		default:
+			if err := os.WriteFile(output, payload, 0600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			fmt.Fprintf(os.Stderr, "📤 Wrote %d spans to %s\n", len(entries), output)
+		}
+
+		if endpoint != "" {
+			headers, err := otlpHeaders(c.StringSlice("header"))
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			if err := history.SendOTLP(endpoint, headers, payload); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "📡 Sent %d spans to %s\n", len(entries), endpoint)
+		}
+
+		return nil
+	},
+}
+
+// otlpHeaders merges OTEL_EXPORTER_OTLP_HEADERS (comma-separated,
+// URL-encoded key=value pairs) with --header flags, which take precedence.
+func otlpHeaders(flags []string) (map[string]string, error) {
+	headers := make(map[string]string)
+	var pairs []string
+	if env := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); env != "" {
+		pairs = strings.Split(env, ",")
+	}
+	for _, pair := range append(pairs, flags...) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid header %q: use key=value", pair)
+		}
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
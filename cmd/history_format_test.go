@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhanurp/jfvm/internal/history"
+)
+
+func formatEntries() []history.Entry {
+	return []history.Entry{
+		{
+			ID: "abc123def456", Version: "2.74.0", Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Command: `rt upload "a, b.txt" repo/`, Duration: 1500, ExitCode: 1, Cwd: "/work",
+			Env: []string{"JFROG_CLI_LOG_LEVEL", "JFROG_CLI_BUILD_NAME"}, Stdout: "line 1\nline \"2\"\n", Stderr: "warn\n",
+		},
+		{ID: "0123456789ab", Version: "2.75.0", Timestamp: time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC), Command: "rt ping"},
+	}
+}
+
+func TestDisplayHistoryCSV(t *testing.T) {
+	tests := []struct {
+		name       string
+		showOutput bool
+		columns    int
+	}{
+		{"without output", false, 12},
+		{"with output", true, 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			displayHistoryCSV(&out, formatEntries(), tt.showOutput)
+
+			records, err := csv.NewReader(&out).ReadAll()
+			if err != nil {
+				t.Fatalf("output is not valid CSV: %v", err)
+			}
+			if len(records) != 3 {
+				t.Fatalf("got %d records, want a header and two rows", len(records))
+			}
+			for i, record := range records {
+				if len(record) != tt.columns {
+					t.Errorf("record %d has %d columns, want %d", i, len(record), tt.columns)
+				}
+			}
+			want := []string{
+				"abc123def456", "2024-05-01T12:00:00Z", "2.74.0", `rt upload "a, b.txt" repo/`, "1500", "1",
+				"/work", "", "", "", "", "JFROG_CLI_LOG_LEVEL;JFROG_CLI_BUILD_NAME",
+			}
+			if tt.showOutput {
+				want = append(want, "line 1\nline \"2\"\n", "warn\n")
+			}
+			if !reflect.DeepEqual(records[1], want) {
+				t.Errorf("row = %q\nwant  %q", records[1], want)
+			}
+			last := "env"
+			if tt.showOutput {
+				last = "stderr"
+			}
+			if header := records[0]; header[0] != "id" || header[len(header)-1] != last {
+				t.Errorf("header = %q, want id through %s", header, last)
+			}
+		})
+	}
+}
+
+func TestDisplayHistoryNDJSON(t *testing.T) {
+	for _, showOutput := range []bool{false, true} {
+		var out bytes.Buffer
+		displayHistoryNDJSON(&out, formatEntries(), showOutput)
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("show output %v: got %d lines, want one per entry", showOutput, len(lines))
+		}
+		var first history.Entry
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+			t.Fatalf("line is not JSON: %v", err)
+		}
+		want := formatEntries()[0]
+		if !showOutput {
+			want.Stdout, want.Stderr = "", ""
+		}
+		if !reflect.DeepEqual(first, want) {
+			t.Errorf("show output %v: entry = %+v\nwant %+v", showOutput, first, want)
+		}
+	}
+}
+
+func TestOTLPHeaders(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "X-Api-Key=s3cr%3Dt, tenant = acme")
+
+	headers, err := otlpHeaders([]string{"tenant=override", "X-Extra=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"X-Api-Key": "s3cr=t", "tenant": "override", "X-Extra": "a=b"}
+	if !reflect.DeepEqual(headers, want) {
+		t.Errorf("headers = %v, want %v", headers, want)
+	}
+
+	if _, err := otlpHeaders([]string{"no-value"}); err == nil {
+		t.Error("a header without = was accepted")
+	}
+}
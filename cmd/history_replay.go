@@ -0,0 +1,459 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal"
+	"github.com/bhanurp/jfvm/internal/history"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// replayOutcome is the result of re-running one recorded entry.
+type replayOutcome struct {
+	Entry    history.Entry
+	Recorded ExecutionResult
+	Fresh    ExecutionResult
+	// StdoutCaptured and StderrCaptured come from the entry: a stream that
+	// was not recorded (a terminal, capture disabled, or `jf c export`) is
+	// not compared.
+	StdoutCaptured bool
+	StderrCaptured bool
+	ExitDiff       bool
+	StdoutDiff     bool
+	StderrDiff     bool
+	Skipped        string
+}
+
+func (o replayOutcome) identical() bool {
+	return o.Skipped == "" && !o.ExitDiff && !o.StdoutDiff && !o.StderrDiff
+}
+
+var historyReplay = &cli.Command{
+	Name:      "replay",
+	Usage:     "Re-run recorded commands with another version and diff against the recorded results",
+	ArgsUsage: "<entry-id> | --all --command <pattern>",
+	Description: "Runs a recorded jf command with a different installed version, in the directory it was recorded in, " +
+		"and compares stdout, stderr and exit code with the recorded ones. Output is only recorded when it did not go to a terminal, so interactive entries compare exit codes only. Entry IDs are shown by 'jfvm history' " +
+		"and may be abbreviated. Replays execute the command again, including any side effects.",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "version",
+			Usage: "Installed version (or alias/range) to replay with (required)",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "Replay every entry matching --command instead of a single ID",
+		},
+		&cli.StringFlag{
+			Name:  "command",
+			Usage: "With --all, replay entries whose command contains this pattern (case-insensitive)",
+		},
+		&cli.StringFlag{
+			Name:  "from-version",
+			Usage: "With --all, only replay entries recorded with this version",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "With --all, replay at most this many distinct invocations, newest first (0 for all)",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "With --all, list the entries that would be replayed",
+		},
+		&cli.BoolFlag{
+			Name:  "show-diffs",
+			Usage: "With --all, show the full comparison for entries that differ",
+		},
+		&cli.BoolFlag{
+			Name:  "fail-on-diff",
+			Usage: "Exit with status 1 if any replay differs from its recording",
+		},
+		&cli.BoolFlag{
+			Name:  "unified",
+			Usage: "Show unified diff format instead of side-by-side",
+		},
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disable colored output",
+		},
+		&cli.IntFlag{
+			Name:  "timeout",
+			Usage: "Command timeout in seconds",
+			Value: 30,
+		},
+		&cli.BoolFlag{
+			Name:  "timing",
+			Usage: "Show execution timing information",
+			Value: true,
+		},
+	}, diffFlags()...),
+	Action: func(c *cli.Context) error {
+		// Allow `replay <id> --version X` as well as flags first
+		args, err := applyTrailingFlags(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if c.String("version") == "" {
+			return cli.Exit("Missing --version: jfvm history replay <entry-id> --version <version>", 1)
+		}
+		opts, err := diffOptionsFrom(c)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if opts.NoColor {
+			color.NoColor = true
+		}
+
+		target, err := internal.Resolver{InstalledOnly: true}.Resolve(c.String("version"))
+		if err != nil {
+			return fmt.Errorf("version %s not found: %w", c.String("version"), err)
+		}
+		if err := utils.CheckVersionExists(target); err != nil {
+			return fmt.Errorf("version %s not found: %w", target, err)
+		}
+
+		redactor, err := history.LoadRedactor()
+		if err != nil {
+			return err
+		}
+		entries, err := history.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load history: %w", err)
+		}
+		timeout := time.Duration(c.Int("timeout")) * time.Second
+
+		if !c.Bool("all") {
+			if len(args) != 1 {
+				return cli.Exit("Usage: jfvm history replay <entry-id> --version <version>", 1)
+			}
+			entry, err := history.Find(entries, args[0])
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			outcome := replayEntry(entry, target, timeout, redactor, opts)
+			if outcome.Skipped != "" {
+				return cli.Exit(fmt.Sprintf("Cannot replay %s: %s", entry.ID, outcome.Skipped), 1)
+			}
+
+			fmt.Printf("🔁 Replaying %s with %s\n", entry.ID, target)
+			fmt.Printf("📝 Command: jf %s\n\n", entry.Command)
+			displayReplay(outcome, opts)
+			if c.Bool("fail-on-diff") && !outcome.identical() {
+				return cli.Exit("", 1)
+			}
+			return nil
+		}
+
+		if len(args) > 0 {
+			return cli.Exit("Pass either an entry ID or --all, not both", 1)
+		}
+		if c.String("command") == "" {
+			return cli.Exit("--all requires --command, since replays run the commands again", 1)
+		}
+
+		selected := replayCandidates(entries, history.Filter{
+			Command: c.String("command"),
+			Version: c.String("from-version"),
+		}, c.Int("limit"))
+		if len(selected) == 0 {
+			fmt.Println("📭 No matching history entries.")
+			return nil
+		}
+
+		if c.Bool("dry-run") {
+			fmt.Printf("🔍 Would replay %d entries with %s:\n", len(selected), target)
+			for _, entry := range selected {
+				fmt.Printf("  %s  %-15s jf %s\n", shortID(entry.ID), entry.Version, entry.Command)
+			}
+			return nil
+		}
+
+		fmt.Printf("🔁 Replaying %d entries with %s\n\n", len(selected), target)
+		outcomes := make([]replayOutcome, 0, len(selected))
+		for _, entry := range selected {
+			outcomes = append(outcomes, replayEntry(entry, target, timeout, redactor, opts))
+		}
+
+		displayReplaySummary(outcomes, target)
+
+		if c.Bool("show-diffs") {
+			for _, outcome := range outcomes {
+				if outcome.Skipped != "" || outcome.identical() {
+					continue
+				}
+				fmt.Printf("\n🔁 %s: jf %s\n", outcome.Entry.ID, outcome.Entry.Command)
+				displayReplay(outcome, opts)
+			}
+		}
+
+		if c.Bool("fail-on-diff") {
+			for _, outcome := range outcomes {
+				if outcome.Skipped == "" && !outcome.identical() {
+					return cli.Exit("", 1)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// applyTrailingFlags sets flags given after positional arguments, which
+// urfave/cli leaves unparsed, and returns the positional arguments.
+func applyTrailingFlags(c *cli.Context) ([]string, error) {
+	var positional []string
+	args := c.Args().Slice()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue {
+			if isBoolFlag(c.Command.Flags, name) {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag %s needs a value", arg)
+			}
+		}
+		if err := c.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid flag %s: %w", arg, err)
+		}
+	}
+	return positional, nil
+}
+
+func isBoolFlag(flags []cli.Flag, name string) bool {
+	for _, flag := range flags {
+		if bf, ok := flag.(*cli.BoolFlag); ok {
+			for _, n := range bf.Names() {
+				if n == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// replayCandidates picks the newest entry for each distinct invocation
+// (arguments and directory) matching the filter.
+func replayCandidates(entries []history.Entry, filter history.Filter, limit int) []history.Entry {
+	seen := make(map[string]bool)
+	var selected []history.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !filter.Match(entry) {
+			continue
+		}
+		key := strings.Join(replayArgs(entry), "\x00") + "\x00" + entry.Cwd
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		selected = append(selected, entry)
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+	}
+	return selected
+}
+
+// replayArgs returns the arguments to run. Entries recorded before exact
+// arguments were kept fall back to splitting the command line.
+func replayArgs(entry history.Entry) []string {
+	if len(entry.Args) > 0 {
+		return entry.Args
+	}
+	return strings.Fields(entry.Command)
+}
+
+func replayEntry(entry history.Entry, version string, timeout time.Duration, redactor *history.Redactor, opts diffOptions) replayOutcome {
+	outcome := replayOutcome{
+		Entry:          entry,
+		StdoutCaptured: entry.StdoutCaptured,
+		StderrCaptured: entry.StderrCaptured,
+		Recorded: ExecutionResult{
+			Version:  entry.Version + " (recorded)",
+			Command:  entry.Command,
+			Output:   entry.Stdout,
+			Stderr:   entry.Stderr,
+			ExitCode: entry.ExitCode,
+			Duration: time.Duration(entry.Duration) * time.Millisecond,
+		},
+	}
+	if entry.ExitCode != 0 && entry.StderrCaptured {
+		outcome.Recorded.ErrorMsg = entry.Stderr
+	}
+
+	args := replayArgs(entry)
+	switch {
+	case len(args) == 0:
+		outcome.Skipped = "no command recorded"
+		return outcome
+	case len(entry.Args) == 0 && strings.Contains(entry.Command, "***"):
+		outcome.Skipped = "the recorded command has redacted values"
+		return outcome
+	}
+
+	// Run where it was recorded so relative paths resolve the same way
+	dir := ""
+	if entry.Cwd != "" {
+		if info, err := os.Stat(entry.Cwd); err == nil && info.IsDir() {
+			dir = entry.Cwd
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠️  %s: recorded directory %s is gone, running in the current directory\n", shortID(entry.ID), entry.Cwd)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	fresh, _ := executeJFCommandWith(ctx, version, args, execOptions{Dir: dir})
+
+	// Normalize like the recording so truncation and masked secrets don't
+	// show up as differences
+	fresh.Output = redactor.NormalizeOutput(fresh.Output)
+	fresh.Stderr = redactor.NormalizeOutput(fresh.Stderr)
+	fresh.ErrorMsg = redactor.NormalizeOutput(fresh.ErrorMsg)
+	// There is nothing to compare a stream with when it was not recorded
+	if !outcome.StdoutCaptured {
+		fresh.Output = ""
+	}
+	if !outcome.StderrCaptured {
+		fresh.Stderr, fresh.ErrorMsg = "", ""
+	}
+	outcome.Fresh = fresh
+
+	outcome.ExitDiff = fresh.ExitCode != entry.ExitCode
+	outcome.StdoutDiff = outcome.StdoutCaptured && !outputsEqual(entry.Stdout, fresh.Output, opts)
+	outcome.StderrDiff = outcome.StderrCaptured &&
+		opts.Mask.Text(strings.TrimSpace(fresh.Stderr)) != opts.Mask.Text(strings.TrimSpace(entry.Stderr))
+	return outcome
+}
+
+func displayReplay(outcome replayOutcome, opts diffOptions) {
+	if note := outcome.captureNote(); note != "" {
+		fmt.Printf("ℹ️  %s\n\n", note)
+	}
+	displayComparison(outcome.Recorded, outcome.Fresh, opts)
+
+	// displayComparison only shows stderr for failed runs
+	if outcome.StderrDiff && outcome.Recorded.ErrorMsg == "" && outcome.Fresh.ErrorMsg == "" {
+		recorded := opts.Mask.Text(strings.TrimSpace(outcome.Recorded.Stderr))
+		fresh := opts.Mask.Text(strings.TrimSpace(outcome.Fresh.Stderr))
+		fmt.Printf("\n📊 STDERR DIFFERENCES:\n")
+		if opts.Unified {
+			displayUnifiedDiff(recorded, fresh, outcome.Recorded.Version, outcome.Fresh.Version, opts.NoColor)
+		} else {
+			displaySideBySideDiff(recorded, fresh, outcome.Recorded.Version, outcome.Fresh.Version, opts.NoColor)
+		}
+	}
+}
+
+// captureNote explains which recorded streams are missing, or is empty
+// when both were captured.
+func (o replayOutcome) captureNote() string {
+	switch {
+	case !o.StdoutCaptured && !o.StderrCaptured:
+		return "Output not recorded; exit code only (terminal output or capture disabled)."
+	case !o.StdoutCaptured:
+		return "Stdout not recorded (terminal output); comparing stderr and exit code."
+	case !o.StderrCaptured:
+		return "Stderr not recorded (terminal output); comparing stdout and exit code."
+	}
+	return ""
+}
+
+func displayReplaySummary(outcomes []replayOutcome, version string) {
+	var (
+		greenColor  = color.New(color.FgGreen)
+		redColor    = color.New(color.FgRed)
+		yellowColor = color.New(color.FgYellow)
+	)
+
+	fmt.Printf("%-10s %-15s %-10s %-8s %-8s %-10s %s\n", "ID", "RECORDED", "EXIT", "STDOUT", "STDERR", "RESULT", "COMMAND")
+	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+
+	var identical, differing, skipped int
+	notRecorded := false
+	for _, o := range outcomes {
+		command := o.Entry.Command
+		if len(command) > 40 {
+			command = command[:37] + "..."
+		}
+
+		if o.Skipped != "" {
+			skipped++
+			fmt.Printf("%-10s %-15s %-10s %-8s %-8s %-10s %s\n", shortID(o.Entry.ID), o.Entry.Version, "-", "-", "-",
+				yellowColor.Sprint("skipped"), command+" ("+o.Skipped+")")
+			continue
+		}
+
+		exit := fmt.Sprintf("%d", o.Entry.ExitCode)
+		if o.ExitDiff {
+			exit = fmt.Sprintf("%d→%d", o.Entry.ExitCode, o.Fresh.ExitCode)
+		}
+		if !o.StdoutCaptured || !o.StderrCaptured {
+			notRecorded = true
+		}
+		result := greenColor.Sprint("same")
+		if o.identical() {
+			identical++
+		} else {
+			differing++
+			result = redColor.Sprint("differs")
+		}
+
+		fmt.Printf("%-10s %-15s %-10s %-8s %-8s %-10s %s\n",
+			shortID(o.Entry.ID),
+			o.Entry.Version,
+			exit,
+			replayCell(o.StdoutDiff, o.StdoutCaptured),
+			replayCell(o.StderrDiff, o.StderrCaptured),
+			result,
+			command)
+	}
+
+	fmt.Printf("\n📊 Replayed with %s: %s identical, %s differ, %s skipped\n",
+		version,
+		greenColor.Sprintf("%d", identical),
+		redColor.Sprintf("%d", differing),
+		yellowColor.Sprintf("%d", skipped))
+	if notRecorded {
+		fmt.Println("ℹ️  n/a: output not recorded (terminal output or capture disabled), so only the exit code and recorded streams are compared")
+	}
+}
+
+func replayCell(differs, captured bool) string {
+	switch {
+	case !captured:
+		return "n/a"
+	case differs:
+		return "diff"
+	}
+	return "same"
+}
+
+// shortID abbreviates an entry ID for tables; Find accepts the prefix.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
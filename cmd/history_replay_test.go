@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal/history"
+)
+
+// fakeVersions installs jf stand-in scripts, keyed by version, under a
+// temporary versions directory.
+func fakeVersions(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake jf binaries are shell scripts")
+	}
+	saved := utils.JfvmVersions
+	utils.JfvmVersions = t.TempDir()
+	t.Cleanup(func() { utils.JfvmVersions = saved })
+
+	for version, script := range scripts {
+		dir := filepath.Join(utils.JfvmVersions, version)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, utils.BinaryName), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplayEntryUsesCaptureFlags(t *testing.T) {
+	fakeVersions(t, map[string]string{"2.75.0": "echo hello\necho warn >&2\n"})
+	redactor, err := history.NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                   string
+		entry                  history.Entry
+		stdoutDiff, stderrDiff bool
+	}{
+		{
+			name:  "nothing recorded",
+			entry: history.Entry{Args: []string{"rt", "ping"}},
+		},
+		{
+			name:       "recorded empty stdout",
+			entry:      history.Entry{Args: []string{"rt", "ping"}, StdoutCaptured: true},
+			stdoutDiff: true,
+		},
+		{
+			name:  "stdout recorded, stderr was a terminal",
+			entry: history.Entry{Args: []string{"rt", "ping"}, Stdout: "hello\n", StdoutCaptured: true},
+		},
+		{
+			name:       "both recorded",
+			entry:      history.Entry{Args: []string{"rt", "ping"}, Stdout: "hello\n", StdoutCaptured: true, StderrCaptured: true},
+			stderrDiff: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.entry.Version = "2.74.0"
+			o := replayEntry(tt.entry, "2.75.0", 10*time.Second, redactor, diffOptions{})
+			if o.Skipped != "" {
+				t.Fatalf("skipped: %s", o.Skipped)
+			}
+			if o.ExitDiff || o.StdoutDiff != tt.stdoutDiff || o.StderrDiff != tt.stderrDiff {
+				t.Errorf("exit/stdout/stderr diff = %v/%v/%v, want false/%v/%v",
+					o.ExitDiff, o.StdoutDiff, o.StderrDiff, tt.stdoutDiff, tt.stderrDiff)
+			}
+			if !tt.entry.StdoutCaptured && o.Fresh.Output != "" {
+				t.Errorf("fresh stdout %q kept for a stream that was not recorded", o.Fresh.Output)
+			}
+			if !tt.entry.StderrCaptured && o.Fresh.Stderr != "" {
+				t.Errorf("fresh stderr %q kept for a stream that was not recorded", o.Fresh.Stderr)
+			}
+		})
+	}
+}
+
+func TestReplayEntryComparesExitCodeWithoutOutput(t *testing.T) {
+	fakeVersions(t, map[string]string{"2.75.0": "echo hello\nexit 3\n"})
+	redactor, err := history.NewRedactor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := replayEntry(history.Entry{Version: "2.74.0", Args: []string{"rt", "ping"}}, "2.75.0", 10*time.Second, redactor, diffOptions{})
+	if !o.ExitDiff || o.identical() {
+		t.Errorf("exit code 0→%d not reported as a difference", o.Fresh.ExitCode)
+	}
+	if o.captureNote() != "Output not recorded; exit code only (terminal output or capture disabled)." {
+		t.Errorf("captureNote = %q", o.captureNote())
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/bhanurp/jfvm/cmd/utils"
 	"github.com/bhanurp/jfvm/internal"
 	"github.com/urfave/cli/v2"
 )
@@ -10,12 +11,44 @@ var Install = &cli.Command{
 	Name:      "install",
 	Usage:     "Install a specific version of JFrog CLI",
 	ArgsUsage: "[version]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mirror",
+			Usage: "Base URL of a release mirror (overrides JFVM_MIRROR and settings.json)",
+		},
+		&cli.StringFlag{
+			Name:  "verify-signature",
+			Usage: "Also verify the detached release signature: gpg, cosign",
+		},
+		&cli.StringFlag{
+			Name:  "signature-key",
+			Usage: "GPG keyring or cosign public key used for signature verification",
+		},
+	},
 	Action: func(c *cli.Context) error {
 		if c.Args().Len() != 1 {
 			return cli.Exit("Please provide a version (e.g., 2.57.0)", 1)
 		}
-		version := c.Args().Get(0)
+		spec := c.Args().Get(0)
+		src, err := internal.ResolveSource(c.String("mirror"))
+		if err != nil {
+			return err
+		}
+
+		version, err := internal.Resolver{Source: src}.Resolve(spec)
+		if err != nil {
+			return fmt.Errorf("failed to resolve version %q: %w", spec, err)
+		}
+		if err := utils.CheckVersionExists(version); err == nil {
+			fmt.Printf("✅ JFrog CLI version %s is already installed\n", version)
+			return nil
+		}
+
 		fmt.Printf("Installing JFrog CLI version: %s\n", version)
-		return internal.DownloadAndInstall(version)
+		return internal.DownloadAndInstall(version, internal.InstallOptions{
+			Source:        src,
+			SignatureMode: c.String("verify-signature"),
+			SignatureKey:  c.String("signature-key"),
+		})
 	},
 }
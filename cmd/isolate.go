@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bhanurp/jfvm/internal/sandbox"
+	"github.com/urfave/cli/v2"
+)
+
+// isolateFlags are shared by compare and benchmark.
+func isolateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "isolate",
+			Usage: "Run each version with its own throwaway JFROG_CLI_HOME_DIR copied from your JFrog CLI config, removed afterwards",
+		},
+		&cli.BoolFlag{
+			Name:  "isolate-workdir",
+			Usage: "With --isolate, also run each version in its own empty temporary directory",
+		},
+	}
+}
+
+// sandboxes holds one sandbox per version for --isolate. A nil
+// *sandboxes means no isolation.
+type sandboxes struct {
+	snapshot  *sandbox.Snapshot
+	byVersion []*sandbox.Sandbox
+	signals   chan os.Signal
+}
+
+// newSandboxes snapshots the JFrog CLI home and creates a sandbox for each
+// version. It returns nil when isolate is false. The sandboxes are removed
+// by Remove, or when jfvm is interrupted.
+func newSandboxes(isolate, workDir bool, versions []string) (*sandboxes, error) {
+	if !isolate {
+		if workDir {
+			return nil, cli.Exit("--isolate-workdir requires --isolate", 1)
+		}
+		return nil, nil
+	}
+
+	snapshot, err := sandbox.Take()
+	if err != nil {
+		return nil, err
+	}
+	s := &sandboxes{snapshot: snapshot}
+	for _, version := range versions {
+		b, err := snapshot.New(version, workDir)
+		if err != nil {
+			s.Remove()
+			return nil, err
+		}
+		s.byVersion = append(s.byVersion, b)
+	}
+
+	// Sandboxes hold copies of credentials; don't leave them behind on ^C
+	s.signals = make(chan os.Signal, 1)
+	signal.Notify(s.signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-s.signals; ok {
+			s.remove()
+			os.Exit(130)
+		}
+	}()
+	return s, nil
+}
+
+// exec adds version vi's sandbox to opts. An explicit working directory
+// (from a scenario) wins over the sandbox's.
+func (s *sandboxes) exec(vi int, opts execOptions) execOptions {
+	if s == nil {
+		return opts
+	}
+	b := s.byVersion[vi]
+	opts.Env = append(append([]string(nil), opts.Env...), b.Env()...)
+	if opts.Dir == "" {
+		opts.Dir = b.WorkDir
+	}
+	return opts
+}
+
+// sandboxPlaceholder stands in for a sandbox's path in compared output,
+// so versions that print their config or working directory still match.
+const sandboxPlaceholder = "<sandbox>"
+
+// normalize replaces version vi's sandbox path in the result's output.
+func (s *sandboxes) normalize(vi int, result ExecutionResult) ExecutionResult {
+	if s == nil {
+		return result
+	}
+	root := s.byVersion[vi].Root
+	result.Output = strings.ReplaceAll(result.Output, root, sandboxPlaceholder)
+	result.Stderr = strings.ReplaceAll(result.Stderr, root, sandboxPlaceholder)
+	result.ErrorMsg = strings.ReplaceAll(result.ErrorMsg, root, sandboxPlaceholder)
+	return result
+}
+
+// describe is the banner line shown in table output.
+func (s *sandboxes) describe() string {
+	workDir := ""
+	if len(s.byVersion) > 0 && s.byVersion[0].WorkDir != "" {
+		workDir = " and working directory"
+	}
+	return fmt.Sprintf("🔒 Isolated: each version gets its own JFROG_CLI_HOME_DIR%s, seeded from %s", workDir, s.snapshot.Source)
+}
+
+// Remove deletes every sandbox and the snapshot.
+func (s *sandboxes) Remove() {
+	if s == nil {
+		return
+	}
+	if s.signals != nil {
+		signal.Stop(s.signals)
+		close(s.signals)
+	}
+	s.remove()
+}
+
+func (s *sandboxes) remove() {
+	for _, b := range s.byVersion {
+		if err := b.Remove(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to remove sandbox %s: %v\n", b.Root, err)
+		}
+	}
+	if err := s.snapshot.Remove(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: failed to remove snapshot: %v\n", err)
+	}
+}
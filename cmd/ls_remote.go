@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bhanurp/jfvm/cmd/descriptions"
+	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+var LsRemote = &cli.Command{
+	Name:        "ls-remote",
+	Usage:       descriptions.LsRemote.Usage,
+	Description: descriptions.LsRemote.Format(),
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "prefix",
+			Usage: "Only show versions in this release line (e.g. 2.74 matches 2.74.x, not 2.740)",
+		},
+		&cli.IntFlag{
+			Name:  "latest",
+			Usage: "Only show the newest N matching versions",
+		},
+		&cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "Ignore the cached listing and query the release repository",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:  "mirror",
+			Usage: "Base URL of a release mirror (overrides JFVM_MIRROR and settings.json)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disable colored output",
+			Value: false,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.Bool("no-color") {
+			color.NoColor = true
+		}
+
+		src, err := internal.ResolveSource(c.String("mirror"))
+		if err != nil {
+			return err
+		}
+
+		versions, err := internal.ListRemoteVersions(src, c.Bool("refresh"))
+		if err != nil {
+			return err
+		}
+
+		if prefix := strings.TrimSuffix(c.String("prefix"), "."); prefix != "" {
+			filtered := []string{}
+			for _, v := range versions {
+				if matchesVersionPrefix(v, prefix) {
+					filtered = append(filtered, v)
+				}
+			}
+			versions = filtered
+		}
+
+		if n := c.Int("latest"); n > 0 && n < len(versions) {
+			versions = versions[:n]
+		}
+
+		if len(versions) == 0 {
+			fmt.Println("📭 No matching remote versions found.")
+			return nil
+		}
+
+		installed := map[string]bool{}
+		names, _ := utils.InstalledVersions()
+		for _, name := range names {
+			installed[name] = true
+		}
+		currentData, _ := os.ReadFile(utils.JfvmConfig)
+		current := strings.TrimSpace(string(currentData))
+
+		greenColor := color.New(color.FgGreen)
+		blueColor := color.New(color.FgBlue)
+
+		fmt.Println("Available versions:")
+		for _, v := range versions {
+			switch {
+			case v == current:
+				fmt.Printf(" - %s %s\n", v, greenColor.Sprint("(current)"))
+			case installed[v]:
+				fmt.Printf(" - %s %s\n", v, blueColor.Sprint("(installed)"))
+			default:
+				fmt.Printf(" - %s\n", v)
+			}
+		}
+		return nil
+	},
+}
+
+// matchesVersionPrefix reports whether version is prefix or lies below it,
+// comparing whole dot-separated segments: 2.7 matches 2.7.1 but not 2.70.0.
+func matchesVersionPrefix(version, prefix string) bool {
+	return version == prefix || strings.HasPrefix(version, prefix+".")
+}
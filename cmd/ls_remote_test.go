@@ -0,0 +1,24 @@
+package cmd
+
+import "testing"
+
+func TestMatchesVersionPrefix(t *testing.T) {
+	tests := []struct {
+		version, prefix string
+		want            bool
+	}{
+		{"2.7.0", "2.7", true},
+		{"2.70.0", "2.7", false},
+		{"2.79.1", "2.7", false},
+		{"2.74.0", "2.74", true},
+		{"2.74.0", "2.74.0", true},
+		{"2.74.10", "2.74.1", false},
+		{"2.74.1", "2", true},
+		{"20.1.0", "2", false},
+	}
+	for _, tt := range tests {
+		if got := matchesVersionPrefix(tt.version, tt.prefix); got != tt.want {
+			t.Errorf("matchesVersionPrefix(%q, %q) = %v, want %v", tt.version, tt.prefix, got, tt.want)
+		}
+	}
+}
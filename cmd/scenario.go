@@ -0,0 +1,572 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bhanurp/jfvm/internal/diff"
+	"github.com/bhanurp/jfvm/internal/scenario"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// scenarioFlags are shared by compare and benchmark.
+func scenarioFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "scenario",
+			Usage: "Run every command of a YAML or JSON scenario file; versions are the only arguments",
+		},
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "With --scenario, how many compare runs execute at once (default: the scenario's parallel setting, else the number of CPUs); benchmarks always run one at a time",
+		},
+	}
+}
+
+// splitVersions flattens versions given as separate arguments or
+// comma-separated.
+func splitVersions(args []string) []string {
+	var versions []string
+	for _, arg := range args {
+		for _, version := range strings.Split(arg, ",") {
+			if version = strings.TrimSpace(version); version != "" {
+				versions = append(versions, version)
+			}
+		}
+	}
+	return versions
+}
+
+func scenarioVersions(args []string, min int) ([]string, error) {
+	for _, arg := range args {
+		if arg == "--" {
+			return nil, cli.Exit("--scenario takes versions only; commands come from the scenario file", 1)
+		}
+	}
+	versions := splitVersions(args)
+	if len(versions) < min {
+		return nil, cli.Exit(fmt.Sprintf("At least %d version(s) required with --scenario", min), 1)
+	}
+	return versions, nil
+}
+
+func scenarioParallel(c *cli.Context, s *scenario.Scenario) int {
+	if n := c.Int("parallel"); n > 0 {
+		return n
+	}
+	if s.Parallel > 0 {
+		return s.Parallel
+	}
+	return runtime.NumCPU()
+}
+
+func scenarioExec(s *scenario.Scenario, command scenario.Command) (execOptions, error) {
+	stdin, err := command.StdinData()
+	if err != nil {
+		return execOptions{}, err
+	}
+	return execOptions{
+		Dir:   s.CommandDir(command),
+		Env:   s.CommandEnv(command),
+		Stdin: stdin,
+	}, nil
+}
+
+// scenarioDiffOptions applies a command's mode and ignore rules on top of
+// the command-line diff options.
+func scenarioDiffOptions(c *cli.Context, opts diffOptions, s *scenario.Scenario, command scenario.Command) (diffOptions, error) {
+	opts.Mode = s.CommandMode(command, opts.Mode)
+	ignore := s.CommandIgnore(command)
+	regexes := append(append([]string(nil), c.StringSlice("ignore-regex")...), ignore.Regex...)
+	paths := append(append([]string(nil), c.StringSlice("ignore-path")...), ignore.Paths...)
+
+	mask, err := diff.NewMask(regexes, paths)
+	if err != nil {
+		return opts, fmt.Errorf("%s: %w", command.Name, err)
+	}
+	if mask.HasPaths() && opts.Mode != modeJSON {
+		return opts, fmt.Errorf("%s: ignore paths require json mode", command.Name)
+	}
+	opts.Mask = mask
+	return opts, nil
+}
+
+// runScenarioTasks calls fn for every command and version, at most
+// parallel at a time.
+func runScenarioTasks(commands, versions, parallel int, fn func(ci, vi int)) {
+	var g errgroup.Group
+	g.SetLimit(parallel)
+	for ci := 0; ci < commands; ci++ {
+		for vi := 0; vi < versions; vi++ {
+			ci, vi := ci, vi
+			g.Go(func() error {
+				fn(ci, vi)
+				return nil
+			})
+		}
+	}
+	g.Wait()
+}
+
+// scenarioCommandReport is one scenario command in the compare report.
+type scenarioCommandReport struct {
+	Name string `json:"name"`
+	compareReport
+	Passed bool `json:"passed"`
+}
+
+// scenarioReport aggregates `jfvm compare --scenario`.
+type scenarioReport struct {
+	Scenario        string                  `json:"scenario"`
+	Versions        []string                `json:"versions"`
+	Baseline        string                  `json:"baseline"`
+	Commands        []scenarioCommandReport `json:"commands"`
+	Passed          int                     `json:"passed"`
+	Failed          int                     `json:"failed"`
+	OutputsDiffer   bool                    `json:"outputs_differ"`
+	ExitCodesDiffer bool                    `json:"exit_codes_differ"`
+	UnexpectedExit  bool                    `json:"unexpected_exit"`
+	TimedOut        bool                    `json:"timed_out"`
+}
+
+func runCompareScenario(c *cli.Context, path string, opts diffOptions, format string) error {
+	s, err := scenario.Load(path)
+	if err != nil {
+		return err
+	}
+	versions, err := scenarioVersions(c.Args().Slice(), 2)
+	if err != nil {
+		return err
+	}
+	resolved, err := validateVersions(versions)
+	if err != nil {
+		return err
+	}
+	baseline, err := findBaseline(c.String("baseline"), versions, resolved)
+	if err != nil {
+		return err
+	}
+
+	// Check every command's settings before running anything
+	commandOpts := make([]diffOptions, len(s.Commands))
+	execs := make([]execOptions, len(s.Commands))
+	for i, command := range s.Commands {
+		if commandOpts[i], err = scenarioDiffOptions(c, opts, s, command); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if execs[i], err = scenarioExec(s, command); err != nil {
+			return err
+		}
+	}
+
+	sb, err := newSandboxes(c.Bool("isolate"), c.Bool("isolate-workdir"), resolved)
+	if err != nil {
+		return err
+	}
+	defer sb.Remove()
+
+	parallel := scenarioParallel(c, s)
+	if format == "table" {
+		fmt.Printf("🧪 Running scenario %s: %d commands × %d versions (%d at a time)\n", s.Name, len(s.Commands), len(resolved), parallel)
+		if sb != nil {
+			fmt.Println(sb.describe())
+		}
+		fmt.Println()
+	}
+
+	runs := make([][]ExecutionResult, len(s.Commands))
+	for i := range runs {
+		runs[i] = make([]ExecutionResult, len(resolved))
+	}
+	fallback := time.Duration(c.Int("timeout")) * time.Second
+	runScenarioTasks(len(s.Commands), len(resolved), parallel, func(ci, vi int) {
+		command := s.Commands[ci]
+		ctx, cancel := context.WithTimeout(context.Background(), s.CommandTimeout(command, fallback))
+		defer cancel()
+		result, _ := executeJFCommandWith(ctx, resolved[vi], command.Args, sb.exec(vi, execs[ci]))
+		runs[ci][vi] = sb.normalize(vi, result)
+	})
+
+	report := scenarioReport{
+		Scenario: s.Name,
+		Versions: resolved,
+		Baseline: resolved[baseline],
+	}
+	for ci, command := range s.Commands {
+		r := buildCompareReport(versions, runs[ci], baseline, command.Args, commandOpts[ci])
+		if command.ExpectExit != nil {
+			r.expectExit(*command.ExpectExit)
+		}
+		entry := scenarioCommandReport{
+			Name:          command.Name,
+			compareReport: r,
+			Passed:        !r.OutputsDiffer && !r.ExitCodesDiffer && !r.UnexpectedExit,
+		}
+		for _, result := range r.Results {
+			if result.TimedOut {
+				entry.Passed = false
+				report.TimedOut = true
+			}
+		}
+		if entry.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.OutputsDiffer = report.OutputsDiffer || r.OutputsDiffer
+		report.ExitCodesDiffer = report.ExitCodesDiffer || r.ExitCodesDiffer
+		report.UnexpectedExit = report.UnexpectedExit || r.UnexpectedExit
+		report.Commands = append(report.Commands, entry)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	case "junit":
+		if err := displayScenarioJUnit(report); err != nil {
+			return err
+		}
+	case "markdown":
+		displayScenarioMarkdown(report)
+	default:
+		displayScenarioTable(report, runs, commandOpts, baseline, c.Bool("show-diffs"))
+	}
+
+	// Expected exit codes are assertions and timeouts are never a pass, so
+	// both always fail the run
+	if report.UnexpectedExit || report.TimedOut {
+		return cli.Exit("", 1)
+	}
+	if c.Bool("fail-on-exit-diff") && report.ExitCodesDiffer {
+		return cli.Exit("", 1)
+	}
+	if c.Bool("fail-on-diff") && report.OutputsDiffer {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// problems describes why a scenario command failed.
+func (r scenarioCommandReport) problems() []string {
+	var problems, outputs []string
+	for _, result := range r.Results {
+		if result.TimedOut {
+			problems = append(problems, "timed out on "+result.Version)
+		} else if result.UnexpectedExit {
+			problems = append(problems, fmt.Sprintf("exit %d on %s, expected %d", result.ExitCode, result.Version, *r.ExpectedExitCode))
+		}
+	}
+	for _, d := range r.Diffs {
+		if d.ExitCodeDiffers {
+			problems = append(problems, fmt.Sprintf("exit code differs on %s", d.Version))
+		}
+		if d.OutputDiffers {
+			outputs = append(outputs, d.Version)
+		}
+	}
+	if len(outputs) > 0 {
+		problems = append(problems, "output differs on "+strings.Join(outputs, ", "))
+	}
+	return problems
+}
+
+func displayScenarioTable(report scenarioReport, runs [][]ExecutionResult, commandOpts []diffOptions, baseline int, showDiffs bool) {
+	var (
+		redColor   = color.New(color.FgRed)
+		greenColor = color.New(color.FgGreen)
+		blueColor  = color.New(color.FgBlue)
+	)
+
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+	fmt.Printf("🧪 SCENARIO RESULTS: %s\n", report.Scenario)
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+	fmt.Printf("Versions: %s (baseline %s)\n\n", strings.Join(report.Versions, ", "), blueColor.Sprint(report.Baseline))
+
+	fmt.Printf("%-6s %-40s %s\n", "STATUS", "COMMAND", "DETAILS")
+	fmt.Printf("─────────────────────────────────────────────────────────────────────────────────────\n")
+	for _, r := range report.Commands {
+		name := r.Name
+		if len(name) > 40 {
+			name = name[:37] + "..."
+		}
+		if r.Passed {
+			fmt.Printf("%s %-40s %s\n", greenColor.Sprintf("%-6s", "PASS"), name, "identical")
+		} else {
+			fmt.Printf("%s %-40s %s\n", redColor.Sprintf("%-6s", "FAIL"), name, strings.Join(r.problems(), "; "))
+		}
+	}
+
+	fmt.Printf("\n📋 Summary: %d commands, %s, %s\n",
+		len(report.Commands),
+		greenColor.Sprintf("%d passed", report.Passed),
+		redColor.Sprintf("%d failed", report.Failed))
+
+	if !showDiffs || report.Failed == 0 {
+		return
+	}
+
+	for ci, r := range report.Commands {
+		if r.Passed {
+			continue
+		}
+		base := runs[ci][baseline]
+		fmt.Printf("\n═══════════════════════════════════════════════════════════════════════════════════\n")
+		fmt.Printf("❌ %s\n", r.Name)
+		fmt.Printf("📝 Command: jf %s\n", r.Command)
+		fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+		for _, problem := range r.problems() {
+			fmt.Printf("   • %s\n", problem)
+		}
+		fmt.Printf("\n")
+
+		for vi, other := range runs[ci] {
+			if vi == baseline {
+				continue
+			}
+			if other.ExitCode != base.ExitCode && other.ErrorMsg != "" {
+				fmt.Printf("   %s ERROR:\n%s\n", redColor.Sprint(other.Version), other.ErrorMsg)
+			}
+			if !outputsEqual(base.Output, other.Output, commandOpts[ci]) {
+				displayOutputDiff(base, other, commandOpts[ci])
+				fmt.Printf("\n")
+			}
+		}
+	}
+}
+
+// junitTestSuites wraps one suite per scenario command.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+func displayScenarioJUnit(report scenarioReport) error {
+	suites := junitTestSuites{Name: "jfvm compare: " + report.Scenario}
+	for _, r := range report.Commands {
+		suite := compareJUnitSuite(r.compareReport, r.Name)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return writeJUnit(suites)
+}
+
+func displayScenarioMarkdown(report scenarioReport) {
+	fmt.Printf("## jfvm compare scenario: %s\n\n", report.Scenario)
+	fmt.Printf("Versions: `%s` (baseline `%s`) · %d passed, %d failed\n\n", strings.Join(report.Versions, "`, `"), report.Baseline, report.Passed, report.Failed)
+
+	fmt.Printf("| Command | Result | Details |\n")
+	fmt.Printf("|---|---|---|\n")
+	for _, r := range report.Commands {
+		if r.Passed {
+			fmt.Printf("| %s | ✅ pass | |\n", r.Name)
+		} else {
+			fmt.Printf("| %s | ❌ fail | %s |\n", r.Name, strings.Join(r.problems(), "; "))
+		}
+	}
+	fmt.Printf("\n")
+
+	for _, r := range report.Commands {
+		if r.Passed {
+			continue
+		}
+		fmt.Printf("### ❌ %s\n\n`jf %s`\n\n", r.Name, r.Command)
+		writeMarkdownComparison(r.compareReport, "####")
+	}
+}
+
+// scenarioBenchmark holds one scenario command's benchmark results.
+type scenarioBenchmark struct {
+	Name    string
+	Command []string
+	Results []BenchmarkResult
+}
+
+func runBenchmarkScenario(c *cli.Context, path string, config BenchmarkConfig) error {
+	s, err := scenario.Load(path)
+	if err != nil {
+		return err
+	}
+	versions, err := scenarioVersions(c.Args().Slice(), 1)
+	if err != nil {
+		return err
+	}
+	resolved, err := validateVersions(versions)
+	if err != nil {
+		return err
+	}
+
+	benchmarks := make([]scenarioBenchmark, len(s.Commands))
+	tasks := make([]benchmarkTask, len(s.Commands))
+	for i, command := range s.Commands {
+		exec, err := scenarioExec(s, command)
+		if err != nil {
+			return err
+		}
+		tasks[i] = benchmarkTask{
+			Command:    command.Args,
+			Iterations: s.CommandIterations(command, config.Iterations),
+			Warmup:     s.CommandWarmup(command, config.Warmup),
+			Timeout:    s.CommandTimeout(command, config.Timeout),
+			Exec:       exec,
+			Stats:      config.Stats,
+		}
+		if command.ExpectExit != nil {
+			tasks[i].ExpectExit = *command.ExpectExit
+		}
+		benchmarks[i] = scenarioBenchmark{
+			Name:    command.Name,
+			Command: command.Args,
+		}
+	}
+
+	sb, err := newSandboxes(config.Isolate, config.IsolateWorkDir, resolved)
+	if err != nil {
+		return err
+	}
+	defer sb.Remove()
+
+	// Timings are only comparable without other runs competing, so
+	// benchmarks ignore parallel and take one command at a time
+	if config.Format == "table" {
+		fmt.Printf("🏁 Benchmarking scenario %s: %d commands × %d versions, one run at a time\n", s.Name, len(s.Commands), len(resolved))
+		if sb != nil {
+			fmt.Println(sb.describe())
+		}
+		fmt.Println()
+	}
+
+	for ci := range benchmarks {
+		versionTasks := make([]benchmarkTask, len(resolved))
+		for vi := range resolved {
+			versionTasks[vi] = tasks[ci]
+			versionTasks[vi].Exec = sb.exec(vi, tasks[ci].Exec)
+		}
+		benchmarks[ci].Results = runBenchmarkRounds(resolved, versionTasks)
+		config.Stats.compareWithFastest(benchmarks[ci].Results)
+	}
+
+	if config.NoColor {
+		color.NoColor = true
+	}
+	switch config.Format {
+	case "json":
+		return displayScenarioBenchmarkJSON(s.Name, benchmarks)
+	case "csv":
+		return displayScenarioBenchmarkCSV(benchmarks)
+	}
+	displayScenarioBenchmarkTable(s.Name, resolved, benchmarks, config.Stats)
+	return nil
+}
+
+func displayScenarioBenchmarkTable(name string, versions []string, benchmarks []scenarioBenchmark, benchStats benchmarkStats) {
+	var (
+		greenColor  = color.New(color.FgGreen, color.Bold)
+		redColor    = color.New(color.FgRed, color.Bold)
+		blueColor   = color.New(color.FgBlue, color.Bold)
+		yellowColor = color.New(color.FgYellow, color.Bold)
+	)
+
+	fmt.Printf("📊 SCENARIO BENCHMARK RESULTS: %s\n", name)
+	fmt.Printf("═══════════════════════════════════════════════════════════════════════════════════\n")
+
+	totals := make(map[string]time.Duration)
+	wins := make(map[string]int)
+	untimed := make(map[string]int)
+	for _, b := range benchmarks {
+		fmt.Printf("\n▶ %s\n", blueColor.Sprint(b.Name))
+		fmt.Printf("  jf %s\n\n", strings.Join(b.Command, " "))
+		displayBenchmarkRows(b.Results, benchStats, greenColor, redColor, blueColor, yellowColor)
+		fmt.Println()
+		displayBenchmarkWinner(b.Results, "  ", greenColor, yellowColor)
+
+		for _, result := range b.Results {
+			totals[result.Version] += result.AverageTime
+			if len(result.Samples) == 0 {
+				untimed[result.Version]++
+			}
+		}
+		// Only significant wins count
+		if winner, _ := benchmarkWinner(b.Results); winner != "" && len(b.Results) > 1 {
+			wins[winner]++
+		}
+	}
+
+	// A version missing timings for some commands has a meaningless total
+	sort.SliceStable(versions, func(i, j int) bool {
+		if completeI, completeJ := untimed[versions[i]] == 0, untimed[versions[j]] == 0; completeI != completeJ {
+			return completeI
+		}
+		return totals[versions[i]] < totals[versions[j]]
+	})
+
+	fmt.Printf("\n🏆 Scenario Summary (sum of average times; wins are significant at %s confidence, %s):\n",
+		formatConfidence(benchStats.Confidence), benchStats.testName())
+	for i, version := range versions {
+		versionColor := blueColor
+		if i == 0 && untimed[version] == 0 {
+			versionColor = greenColor
+		}
+		fmt.Printf("   %s %s, significantly fastest in %d of %d commands",
+			versionColor.Sprintf("%-15s", version),
+			formatDuration(totals[version]),
+			wins[version],
+			len(benchmarks))
+		if untimed[version] > 0 {
+			fmt.Print(redColor.Sprintf(" (no successful runs in %d, not counted)", untimed[version]))
+		}
+		fmt.Println()
+	}
+}
+
+func displayScenarioBenchmarkJSON(name string, benchmarks []scenarioBenchmark) error {
+	type commandRecord struct {
+		Name             string            `json:"name"`
+		Command          string            `json:"command"`
+		BenchmarkResults []benchmarkRecord `json:"benchmark_results"`
+	}
+	out := struct {
+		Scenario string          `json:"scenario"`
+		Commands []commandRecord `json:"commands"`
+	}{Scenario: name}
+	for _, b := range benchmarks {
+		out.Commands = append(out.Commands, commandRecord{
+			Name:             b.Name,
+			Command:          strings.Join(b.Command, " "),
+			BenchmarkResults: newBenchmarkRecords(b.Results),
+		})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func displayScenarioBenchmarkCSV(benchmarks []scenarioBenchmark) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write(append([]string{"command"}, strings.Split(benchmarkCSVHeader, ",")...))
+	for _, b := range benchmarks {
+		for _, result := range b.Results {
+			w.Write(append([]string{b.Name}, benchmarkCSVFields(result)...))
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bhanurp/jfvm/cmd/descriptions"
+	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal"
+	"github.com/urfave/cli/v2"
+)
+
+// SessionVersionEnv overrides the global version for a single shell session.
+const SessionVersionEnv = "JFVM_VERSION"
+
+var Shell = &cli.Command{
+	Name:        "shell",
+	Usage:       descriptions.Shell.Usage,
+	ArgsUsage:   "[version or alias]",
+	Description: descriptions.Shell.Format(),
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "shell",
+			Usage: "Shell syntax to print: bash, zsh, fish, powershell (default: detected from $SHELL)",
+		},
+		&cli.BoolFlag{
+			Name:  "unset",
+			Usage: "Print the command that removes the session override",
+			Value: false,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		shell := c.String("shell")
+		if shell == "" {
+			shell = detectShell()
+		}
+
+		if c.Bool("unset") {
+			line, err := unsetLine(shell)
+			if err != nil {
+				return err
+			}
+			fmt.Println(line)
+			return nil
+		}
+
+		if c.Args().Len() != 1 {
+			return cli.Exit("Usage: eval \"$(jfvm shell <version>)\"", 1)
+		}
+		spec := c.Args().Get(0)
+
+		version, err := internal.Resolver{InstalledOnly: true}.Resolve(spec)
+		if err != nil {
+			return fmt.Errorf("failed to resolve version %q: %w", spec, err)
+		}
+		if err := utils.CheckVersionExists(version); err != nil {
+			return fmt.Errorf("version %s is not installed, run 'jfvm install %s' first", version, version)
+		}
+
+		line, err := exportLine(shell, version)
+		if err != nil {
+			return err
+		}
+		fmt.Println(line)
+
+		// stdout is meant for eval; only hint when a human is looking at it
+		if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprintf(os.Stderr, "# Run this to apply it: eval \"$(jfvm shell %s)\"\n", spec)
+		}
+		return nil
+	},
+}
+
+func detectShell() string {
+	if s := filepath.Base(os.Getenv("SHELL")); s != "." && s != "/" && s != "" {
+		return s
+	}
+	return "bash"
+}
+
+func exportLine(shell, version string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh", "ksh":
+		return fmt.Sprintf("export %s=%s", SessionVersionEnv, shellQuote(version)), nil
+	case "fish":
+		return fmt.Sprintf("set -gx %s %s;", SessionVersionEnv, shellQuote(version)), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf("$env:%s = %s", SessionVersionEnv, shellQuote(version)), nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s", shell)
+}
+
+func unsetLine(shell string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh", "ksh":
+		return "unset " + SessionVersionEnv, nil
+	case "fish":
+		return "set -e " + SessionVersionEnv + ";", nil
+	case "powershell", "pwsh":
+		return "Remove-Item Env:" + SessionVersionEnv, nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s", shell)
+}
+
+// shellQuote wraps s in single quotes, which all supported shells treat
+// literally.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
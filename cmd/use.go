@@ -17,38 +17,47 @@ var Use = &cli.Command{
 	Usage:       descriptions.Use.Usage,
 	ArgsUsage:   "[version or alias] (optional if .jfrog-version exists)",
 	Description: descriptions.Use.Format(),
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "mirror",
+			Usage: "Base URL of a release mirror used if the version must be installed",
+		},
+	},
 	Action: func(c *cli.Context) error {
 		fmt.Println("Executing 'jfvm use' command...")
-		var version string
+		var spec string
 
 		if c.Args().Len() == 1 {
-			v := c.Args().Get(0)
-			fmt.Printf("Received argument: %s\n", v)
-
-			// Try to resolve alias (silently fallback if not found)
-			resolved, err := utils.ResolveAlias(v)
-			if err == nil {
-				version = strings.TrimSpace(resolved)
-				fmt.Printf("Using alias '%s' resolved to version: %s\n", v, version)
-			} else {
-				// don't log anything — just fallback silently
-				version = v
-			}
+			spec = c.Args().Get(0)
+			fmt.Printf("Received argument: %s\n", spec)
 		} else {
 			v, err := utils.GetVersionFromProjectFile()
 			if err != nil {
 				return cli.Exit("No version provided and no .jfrog-version file found", 1)
 			}
-			version = v
-			fmt.Printf("Using version from .jfrog-version: %s\n", version)
+			spec = strings.TrimSpace(v)
+			fmt.Printf("Using version from .jfrog-version: %s\n", spec)
+		}
+
+		src, err := internal.ResolveSource(c.String("mirror"))
+		if err != nil {
+			return err
+		}
+
+		version, err := internal.Resolver{Source: src}.Resolve(spec)
+		if err != nil {
+			return fmt.Errorf("failed to resolve version %q: %w", spec, err)
+		}
+		if version != spec {
+			fmt.Printf("Resolved '%s' to version: %s\n", spec, version)
 		}
 
 		binPath := filepath.Join(utils.JfvmVersions, version, utils.BinaryName)
 		fmt.Printf("Checking if binary exists at: %s\n", binPath)
 
-		if _, err := os.Stat(binPath); os.IsNotExist(err) {
+		if err := utils.CheckVersionExists(version); err != nil {
 			fmt.Printf("Version %s not found locally. Installing...\n", version)
-			if err := internal.DownloadAndInstall(version); err != nil {
+			if err := internal.DownloadAndInstall(version, internal.InstallOptions{Source: src}); err != nil {
 				return fmt.Errorf("auto-install failed: %w", err)
 			}
 		}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,12 +9,16 @@ import (
 )
 
 const (
-	ToolName    = "jfvm"
-	ConfigFile  = "config"
-	VersionsDir = "versions"
-	BinaryName  = "jf"
-	ProjectFile = ".jfrog-version"
-	AliasesDir  = "aliases"
+	ToolName     = "jfvm"
+	ConfigFile   = "config"
+	VersionsDir  = "versions"
+	BinaryName   = "jf"
+	ProjectFile  = ".jfrog-version"
+	AliasesDir   = "aliases"
+	StagingDir   = "tmp"
+	SettingsFile = "settings.json"
+	CacheDir     = "cache"
+	LocksDir     = "locks"
 )
 
 var (
@@ -22,19 +27,129 @@ var (
 	JfvmConfig   = filepath.Join(JfvmRoot, ConfigFile)
 	JfvmVersions = filepath.Join(JfvmRoot, VersionsDir)
 	JfvmAliases  = filepath.Join(JfvmRoot, AliasesDir)
+	JfvmStaging  = filepath.Join(JfvmRoot, StagingDir)
+	JfvmSettings = filepath.Join(JfvmRoot, SettingsFile)
+	JfvmCache    = filepath.Join(JfvmRoot, CacheDir)
+	JfvmLocks    = filepath.Join(JfvmRoot, LocksDir)
 )
 
+// Settings holds optional user configuration read from ~/.jfvm/settings.json.
+type Settings struct {
+	Mirror MirrorSettings `json:"mirror,omitempty"`
+	// Channels maps names such as "lts" to a version or constraint.
+	Channels map[string]string `json:"channels,omitempty"`
+	// ProjectBoundary stops the upward .jfrog-version search, like $HOME.
+	ProjectBoundary string `json:"project_boundary,omitempty"`
+	// AutoInstall lets the shim install a missing version on first use.
+	AutoInstall bool            `json:"auto_install,omitempty"`
+	History     HistorySettings `json:"history,omitempty"`
+}
+
+// HistorySettings controls what the shim records about jf invocations.
+type HistorySettings struct {
+	// Disabled stops recording entirely; the shim then replaces itself with
+	// jf instead of running it as a child process.
+	Disabled bool `json:"disabled,omitempty"`
+	// RedactPatterns are extra regular expressions masked in recorded
+	// commands and output, on top of the built-in secret rules.
+	RedactPatterns []string `json:"redact_patterns,omitempty"`
+	// Retention limits; zero values fall back to the built-in defaults.
+	MaxEntries int    `json:"max_entries,omitempty"`
+	MaxAge     string `json:"max_age,omitempty"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"`
+	// CaptureOutput set to false records commands without their output.
+	CaptureOutput *bool `json:"capture_output,omitempty"`
+}
+
+// MirrorSettings points downloads at a release mirror such as an Artifactory
+// remote repository instead of releases.jfrog.io.
+type MirrorSettings struct {
+	URL      string `json:"url,omitempty"`
+	Layout   string `json:"layout,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// LoadSettings reads the settings file. A missing file yields empty settings.
+func LoadSettings() (Settings, error) {
+	var settings Settings
+	data, err := os.ReadFile(JfvmSettings)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid %s: %w", JfvmSettings, err)
+	}
+	return settings, nil
+}
+
 func GetVersionFromProjectFile() (string, error) {
 	fmt.Println("Attempting to read .jfrog-version file...")
-	data, err := os.ReadFile(ProjectFile)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	path, err := FindProjectFile(cwd)
+	if err != nil {
+		fmt.Printf("Failed to find .jfrog-version file: %v\n", err)
+		return "", err
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
 		fmt.Printf("Failed to read .jfrog-version file: %v\n", err)
 		return "", err
 	}
-	fmt.Printf(".jfrog-version content: %s\n", string(data))
+	fmt.Printf(".jfrog-version content (%s): %s\n", path, string(data))
 	return string(data), nil
 }
 
+// FindProjectFile walks from dir towards the filesystem root looking for a
+// .jfrog-version file. The search does not go above $HOME or the boundary
+// set in JFVM_PROJECT_BOUNDARY / settings.json.
+func FindProjectFile(dir string) (string, error) {
+	settings, _ := LoadSettings()
+	return FindProjectFileWithin(dir, ProjectBoundary(settings))
+}
+
+// FindProjectFileWithin is FindProjectFile with the boundary already
+// resolved, for callers that have loaded settings.
+func FindProjectFileWithin(dir, boundary string) (string, error) {
+	stops := map[string]bool{}
+	if HomeDir != "" {
+		stops[filepath.Clean(HomeDir)] = true
+	}
+	if boundary != "" {
+		stops[filepath.Clean(boundary)] = true
+	}
+
+	dir = filepath.Clean(dir)
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if stops[dir] || parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
+// ProjectBoundary returns JFVM_PROJECT_BOUNDARY, else the boundary from
+// settings.
+func ProjectBoundary(settings Settings) string {
+	if b := os.Getenv("JFVM_PROJECT_BOUNDARY"); b != "" {
+		return b
+	}
+	return settings.ProjectBoundary
+}
+
 func ResolveAlias(name string) (string, error) {
 	path := filepath.Join(JfvmAliases, name)
 	data, err := os.ReadFile(path)
@@ -56,6 +171,43 @@ func ResolveVersionOrAlias(name string) (string, error) {
 	return name, nil
 }
 
+// IsTruthy reports whether an environment-style value means "enabled".
+func IsTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// JFrogCLIHome returns the JFrog CLI home directory: JFROG_CLI_HOME_DIR,
+// default ~/.jfrog.
+func JFrogCLIHome() string {
+	if home := os.Getenv("JFROG_CLI_HOME_DIR"); home != "" {
+		return home
+	}
+	return filepath.Join(HomeDir, ".jfrog")
+}
+
+// InstalledVersions returns the names of all version directories.
+func InstalledVersions() ([]string, error) {
+	entries, err := os.ReadDir(JfvmVersions)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+	return versions, nil
+}
+
 // CheckVersionExists verifies that a version directory and binary exist
 func CheckVersionExists(version string) error {
 	versionDir := filepath.Join(JfvmVersions, version)
@@ -66,10 +218,14 @@ func CheckVersionExists(version string) error {
 		return fmt.Errorf("version directory does not exist")
 	}
 
-	// Check if binary exists
-	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
+	// Check if binary exists and is not a leftover from an interrupted install
+	info, err := os.Stat(binaryPath)
+	if os.IsNotExist(err) {
 		return fmt.Errorf("binary not found in version directory")
 	}
+	if err == nil && info.Size() == 0 {
+		return fmt.Errorf("binary is empty (incomplete install)")
+	}
 
 	return nil
 }
@@ -2,16 +2,20 @@ module github.com/bhanurp/jfvm
 
 go 1.24
 
-require github.com/urfave/cli/v2 v2.27.6
+require (
+	github.com/Masterminds/semver/v3 v3.5.0
+	github.com/fatih/color v1.16.0
+	github.com/mattn/go-isatty v0.0.20
+	github.com/sergi/go-diff v1.3.1
+	github.com/urfave/cli/v2 v2.27.6
+	golang.org/x/sync v0.6.0
+	golang.org/x/sys v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.7 // indirect
-	github.com/fatih/color v1.16.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/sergi/go-diff v1.3.1 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
-	golang.org/x/sync v0.6.0 // indirect
-	golang.org/x/sys v0.14.0 // indirect
 )
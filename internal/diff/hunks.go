@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hunk is one block of a unified diff. Lines carry a " ", "-" or "+"
+// prefix.
+type Hunk struct {
+	OldStart int      `json:"old_start"`
+	OldLines int      `json:"old_lines"`
+	NewStart int      `json:"new_start"`
+	NewLines int      `json:"new_lines"`
+	Lines    []string `json:"lines"`
+}
+
+// Header returns the hunk's "@@ -1,3 +1,4 @@" line.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// Hunks groups a line diff into unified diff hunks with context unchanged
+// lines around each change. Changes closer than twice the context share a
+// hunk.
+func Hunks(ops []Op, context int) []Hunk {
+	// oldLine[i] and newLine[i] count the lines consumed before ops[i]
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.Kind != Insert {
+			oldLine[i+1]++
+		}
+		if op.Kind != Delete {
+			newLine[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		start := max(0, i-context)
+		end := i
+		for {
+			for end < len(ops) && ops[end].Kind != Equal {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].Kind == Equal {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := min(len(ops), end+context)
+
+		hunk := Hunk{
+			OldStart: oldLine[start] + 1,
+			OldLines: oldLine[stop] - oldLine[start],
+			NewStart: newLine[start] + 1,
+			NewLines: newLine[stop] - newLine[start],
+		}
+		// An empty side points at the line before it, as diff -u does
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		for _, op := range ops[start:stop] {
+			switch op.Kind {
+			case Equal:
+				hunk.Lines = append(hunk.Lines, " "+op.Old)
+			case Delete:
+				hunk.Lines = append(hunk.Lines, "-"+op.Old)
+			case Insert:
+				hunk.Lines = append(hunk.Lines, "+"+op.New)
+			}
+		}
+		hunks = append(hunks, hunk)
+		i = stop
+	}
+	return hunks
+}
+
+// Unified renders hunks as the body of a unified diff.
+func Unified(hunks []Hunk) string {
+	var b strings.Builder
+	for _, hunk := range hunks {
+		b.WriteString(hunk.Header())
+		b.WriteString("\n")
+		for _, line := range hunk.Lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
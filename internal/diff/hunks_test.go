@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// numbered returns the lines l01 to ln, leaving out skip.
+func numbered(n int, skip ...string) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		if line := fmt.Sprintf("l%02d", i); !slices.Contains(skip, line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new []string
+		context  int
+		headers  []string
+	}{
+		{"no changes", numbered(5), numbered(5), 3, nil},
+		{"one change", numbered(10), slices.Replace(numbered(10), 4, 5, "new"), 3, []string{"@@ -2,7 +2,7 @@"}},
+		{"far apart changes stay separate", numbered(10), numbered(10, "l02", "l08"), 2, []string{"@@ -1,4 +1,3 @@", "@@ -6,5 +5,4 @@"}},
+		{"close changes share a hunk", numbered(10), numbered(10, "l02", "l08"), 3, []string{"@@ -1,10 +1,8 @@"}},
+		{"no context", numbered(10), numbered(10, "l02", "l08"), 0, []string{"@@ -2,1 +1,0 @@", "@@ -8,1 +6,0 @@"}},
+		{"into empty output", nil, []string{"a", "b"}, 3, []string{"@@ -0,0 +1,2 @@"}},
+		{"to empty output", []string{"a", "b"}, nil, 3, []string{"@@ -1,2 +0,0 @@"}},
+		{"deleted last line", []string{"a", "b", "c"}, []string{"a", "b"}, 1, []string{"@@ -2,2 +2,1 @@"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			for _, hunk := range Hunks(Lines(tt.old, tt.new), tt.context) {
+				headers = append(headers, hunk.Header())
+			}
+			if !reflect.DeepEqual(headers, tt.headers) {
+				t.Errorf("headers = %q, want %q", headers, tt.headers)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	old := []string{"l01", "l02", "l03", "l04", "l05", "l06"}
+	new := []string{"l01", "l02", "l03", "x04", "l05", "l06", "l07"}
+	want := "@@ -3,4 +3,5 @@\n l03\n-l04\n+x04\n l05\n l06\n+l07\n"
+	if got := Unified(Hunks(Lines(old, new), 1)); got != want {
+		t.Errorf("Unified =\n%s\nwant\n%s", got, want)
+	}
+}
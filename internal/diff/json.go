@@ -0,0 +1,171 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a JSON difference.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is one path-level JSON difference, such as
+// $.files[3].size: 10 → 12.
+type Change struct {
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	Old  any        `json:"old,omitempty"`
+	New  any        `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s: + %s", c.Path, FormatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("%s: - %s", c.Path, FormatValue(c.Old))
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Path, FormatValue(c.Old), FormatValue(c.New))
+}
+
+// ParseJSON decodes a JSON document. Output holding several documents one
+// after another (as some jf commands print) is treated as an array of them.
+func ParseJSON(data string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+
+	var docs []any
+	for {
+		var doc any
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	switch len(docs) {
+	case 0:
+		return nil, fmt.Errorf("no JSON document")
+	case 1:
+		return docs[0], nil
+	}
+	return docs, nil
+}
+
+// JSON returns the path-level differences between two decoded documents,
+// sorted by path. Object key order never matters; array elements are
+// compared by index.
+func JSON(old, new any, mask *Mask) []Change {
+	var changes []Change
+	walk("$", old, new, mask, &changes)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func walk(path string, old, new any, mask *Mask, changes *[]Change) {
+	if mask.IgnoresPath(path) {
+		return
+	}
+
+	switch o := old.(type) {
+	case map[string]any:
+		if n, ok := new.(map[string]any); ok {
+			keys := make(map[string]bool)
+			for k := range o {
+				keys[k] = true
+			}
+			for k := range n {
+				keys[k] = true
+			}
+			for k := range keys {
+				child := childPath(path, k)
+				ov, inOld := o[k]
+				nv, inNew := n[k]
+				switch {
+				case !inNew:
+					if !mask.IgnoresPath(child) {
+						*changes = append(*changes, Change{Path: child, Kind: Removed, Old: ov})
+					}
+				case !inOld:
+					if !mask.IgnoresPath(child) {
+						*changes = append(*changes, Change{Path: child, Kind: Added, New: nv})
+					}
+				default:
+					walk(child, ov, nv, mask, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if n, ok := new.([]any); ok {
+			for i := 0; i < max(len(o), len(n)); i++ {
+				child := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(n):
+					if !mask.IgnoresPath(child) {
+						*changes = append(*changes, Change{Path: child, Kind: Removed, Old: o[i]})
+					}
+				case i >= len(o):
+					if !mask.IgnoresPath(child) {
+						*changes = append(*changes, Change{Path: child, Kind: Added, New: n[i]})
+					}
+				default:
+					walk(child, o[i], n[i], mask, changes)
+				}
+			}
+			return
+		}
+	}
+
+	if mask.scalar(old) != mask.scalar(new) {
+		*changes = append(*changes, Change{Path: path, Kind: Changed, Old: old, New: new})
+	}
+}
+
+// scalar renders a leaf (or a leaf-vs-container mismatch) for comparison,
+// with ignore patterns applied to strings.
+func (m *Mask) scalar(v any) string {
+	if s, ok := v.(string); ok {
+		return "s" + m.Text(s)
+	}
+	data, _ := json.Marshal(v)
+	return "j" + string(data)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func childPath(parent, key string) string {
+	if identifier.MatchString(key) {
+		return parent + "." + key
+	}
+	return parent + "[" + strconv.Quote(key) + "]"
+}
+
+// FormatValue renders a JSON value compactly for display.
+func FormatValue(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	s := strings.TrimSpace(buf.String())
+	if len(s) > 80 {
+		s = s[:77] + "..."
+	}
+	return s
+}
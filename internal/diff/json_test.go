@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func parse(t *testing.T, s string) any {
+	t.Helper()
+	v, err := ParseJSON(s)
+	if err != nil {
+		t.Fatalf("ParseJSON(%s): %v", s, err)
+	}
+	return v
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"key order ignored", `{"a": 1, "b": [1, 2]}`, `{"b": [1, 2], "a": 1}`, nil},
+		{"changed value", `{"a": 1}`, `{"a": 2}`, []string{"$.a: 1 → 2"}},
+		{"added and removed keys", `{"a": 1, "c": "x"}`, `{"a": 1, "b": true}`, []string{"$.b: + true", `$.c: - "x"`}},
+		{"nested array element", `{"files": [{"size": 10}]}`, `{"files": [{"size": 12}]}`, []string{"$.files[0].size: 10 → 12"}},
+		{"longer array", `[1, 2]`, `[1, 2, 3]`, []string{"$[2]: + 3"}},
+		{"shorter array", `[1, 2, 3]`, `[1]`, []string{"$[1]: - 2", "$[2]: - 3"}},
+		{"quoted key", `{"a b": 1}`, `{"a b": 2}`, []string{`$["a b"]: 1 → 2`}},
+		{"string versus number", `{"a": "1"}`, `{"a": 1}`, []string{`$.a: "1" → 1`}},
+		{"object versus null", `{"a": {"b": 1}}`, `{"a": null}`, []string{`$.a: {"b":1} → null`}},
+		{"large numbers kept exact", `{"n": 9007199254740993}`, `{"n": 9007199254740992}`, []string{"$.n: 9007199254740993 → 9007199254740992"}},
+		{"sorted by path", `{"z": 1, "a": 1, "m": 1}`, `{"z": 2, "a": 2, "m": 2}`, []string{"$.a: 1 → 2", "$.m: 1 → 2", "$.z: 1 → 2"}},
+		{"several documents", "{\"a\": 1}\n{\"a\": 2}\n", "{\"a\": 1}\n{\"a\": 3}\n", []string{"$[1].a: 2 → 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range JSON(parse(t, tt.old), parse(t, tt.new), nil) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONWithMask(t *testing.T) {
+	old := `{"id": 1, "created": "2024-01-01T10:00:00Z", "files": [{"name": "a", "modified": 1}], "meta": {"took": "5ms"}}`
+	new := `{"id": 1, "created": "2024-02-02T11:30:00Z", "files": [{"name": "b", "modified": 2}], "meta": {"took": "9ms", "node": "n2"}}`
+
+	tests := []struct {
+		name           string
+		regexes, paths []string
+		want           []string
+	}{
+		{"nothing ignored", nil, nil, []string{
+			`$.created: "2024-01-01T10:00:00Z" → "2024-02-02T11:30:00Z"`,
+			"$.files[0].modified: 1 → 2",
+			`$.files[0].name: "a" → "b"`,
+			`$.meta.node: + "n2"`,
+			`$.meta.took: "5ms" → "9ms"`,
+		}},
+		{"regex masks string values", []string{`\d{4}-\d\d-\d\dT[\d:]+Z`, `\d+ms`}, nil, []string{
+			"$.files[0].modified: 1 → 2",
+			`$.files[0].name: "a" → "b"`,
+			`$.meta.node: + "n2"`,
+		}},
+		{"paths ignore subtrees and added keys", nil, []string{"$.meta", "$.files[*].modified", "$..created"}, []string{
+			`$.files[0].name: "a" → "b"`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask, err := NewMask(tt.regexes, tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, change := range JSON(parse(t, old), parse(t, new), mask) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	if v := parse(t, "[1]\n[2]"); !reflect.DeepEqual(v, []any{[]any{json.Number("1")}, []any{json.Number("2")}}) {
+		t.Errorf("two documents = %#v, want an array of both", v)
+	}
+	for _, bad := range []string{"", "  \n", "{", "not json", "[1] trailing"} {
+		if _, err := ParseJSON(bad); err == nil {
+			t.Errorf("ParseJSON(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	long := make([]any, 40)
+	for i := range long {
+		long[i] = "x"
+	}
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{"a<b>", `"a<b>"`},
+		{map[string]any{"k": []any{1, true, nil}}, `{"k":[1,true,null]}`},
+		{long, `["x","x","x","x","x","x","x","x","x","x","x","x","x","x","x","x","x","x","x",...`},
+	}
+	for _, tt := range tests {
+		if got := FormatValue(tt.value); got != tt.want {
+			t.Errorf("FormatValue(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
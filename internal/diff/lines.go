@@ -0,0 +1,149 @@
+// Package diff compares command output as lines or as JSON documents.
+package diff
+
+import "strings"
+
+// OpKind says how a line moves from the old output to the new one.
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Delete
+	Insert
+)
+
+// Op is one line of a line diff. Delete ops carry Old, Insert ops New and
+// Equal ops both.
+type Op struct {
+	Kind OpKind
+	Old  string
+	New  string
+}
+
+// SplitLines splits output into lines; empty output has none.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// maxTableCells bounds the LCS table; larger inputs fall back to a plain
+// delete/insert of the differing middle.
+const maxTableCells = 16 << 20
+
+// Lines returns a minimal line diff of a and b based on their longest
+// common subsequence.
+func Lines(a, b []string) []Op {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]Op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, Op{Equal, line, line})
+	}
+	ops = append(ops, lcs(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, Op{Equal, line, line})
+	}
+	return ops
+}
+
+func lcs(a, b []string) []Op {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 || n*m > maxTableCells {
+		ops := make([]Op, 0, n+m)
+		for _, line := range a {
+			ops = append(ops, Op{Kind: Delete, Old: line})
+		}
+		for _, line := range b {
+			ops = append(ops, Op{Kind: Insert, New: line})
+		}
+		return ops
+	}
+
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	width := m + 1
+	table := make([]int32, (n+1)*width)
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	ops := make([]Op, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{Equal, a[i], b[j]})
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			ops = append(ops, Op{Kind: Delete, Old: a[i]})
+			i++
+		default:
+			ops = append(ops, Op{Kind: Insert, New: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Op{Kind: Delete, Old: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Op{Kind: Insert, New: b[j]})
+	}
+	return ops
+}
+
+// Row is one line of a side-by-side view: a changed pair, a line only on
+// one side, or an unchanged line.
+type Row struct {
+	Old, New       string
+	HasOld, HasNew bool
+	Changed        bool
+}
+
+// SideBySide pairs deletions with the insertions that follow them so a
+// modified line shows up on one row.
+func SideBySide(ops []Op) []Row {
+	var rows []Row
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == Equal {
+			rows = append(rows, Row{Old: ops[i].Old, New: ops[i].New, HasOld: true, HasNew: true})
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(ops) && ops[i].Kind != Equal; i++ {
+			if ops[i].Kind == Delete {
+				deleted = append(deleted, ops[i].Old)
+			} else {
+				inserted = append(inserted, ops[i].New)
+			}
+		}
+		for k := 0; k < max(len(deleted), len(inserted)); k++ {
+			row := Row{Changed: true}
+			if k < len(deleted) {
+				row.Old, row.HasOld = deleted[k], true
+			}
+			if k < len(inserted) {
+				row.New, row.HasNew = inserted[k], true
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// render writes ops as "=line", "-line" and "+line" for comparison.
+func render(ops []Op) []string {
+	out := []string{}
+	for _, op := range ops {
+		switch op.Kind {
+		case Equal:
+			out = append(out, "="+op.Old)
+		case Delete:
+			out = append(out, "-"+op.Old)
+		case Insert:
+			out = append(out, "+"+op.New)
+		}
+	}
+	return out
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"identical", "a b c", "a b c", []string{"=a", "=b", "=c"}},
+		{"both empty", "", "", []string{}},
+		{"all inserted", "", "a b", []string{"+a", "+b"}},
+		{"all deleted", "a b", "", []string{"-a", "-b"}},
+		{"modified line", "a b c", "a x c", []string{"=a", "-b", "+x", "=c"}},
+		{"inserted line", "a c", "a b c", []string{"=a", "+b", "=c"}},
+		{"shifted window", "a b c d", "b c d e", []string{"-a", "=b", "=c", "=d", "+e"}},
+		{"deletions before insertions on ties", "a b", "b a", []string{"-a", "=b", "+a"}},
+		{"longest common run kept", "x a b c y", "a b c z", []string{"-x", "=a", "=b", "=c", "-y", "+z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := render(Lines(strings.Fields(tt.old), strings.Fields(tt.new)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	if got := SplitLines(""); got != nil {
+		t.Errorf("SplitLines(\"\") = %q, want no lines", got)
+	}
+	if got := SplitLines("a\nb\n"); !reflect.DeepEqual(got, []string{"a", "b", ""}) {
+		t.Errorf("SplitLines = %q, want the trailing empty line kept", got)
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	ops := Lines([]string{"a", "b", "c", "d"}, []string{"a", "x", "y", "c"})
+	want := []Row{
+		{Old: "a", New: "a", HasOld: true, HasNew: true},
+		{Old: "b", New: "x", HasOld: true, HasNew: true, Changed: true},
+		{New: "y", HasNew: true, Changed: true},
+		{Old: "c", New: "c", HasOld: true, HasNew: true},
+		{Old: "d", HasOld: true, Changed: true},
+	}
+	if got := SideBySide(ops); !reflect.DeepEqual(got, want) {
+		t.Errorf("SideBySide =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := SideBySide(nil); got != nil {
+		t.Errorf("SideBySide(nil) = %+v, want no rows", got)
+	}
+}
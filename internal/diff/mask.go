@@ -0,0 +1,108 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Masked replaces text matched by an ignore regex.
+const Masked = "<ignored>"
+
+// Mask hides volatile parts of output, such as timestamps and durations,
+// before comparing. A nil Mask ignores nothing.
+type Mask struct {
+	regexps []*regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// NewMask compiles ignore regexes, applied to text and to JSON string
+// values, and JSON path patterns. Path patterns use the notation of
+// Change.Path with wildcards: [*] matches any index, .* any key, and ..key
+// matches key at any depth, e.g. $.files[*].modified or $..created.
+// Ignoring a path ignores everything below it.
+func NewMask(regexes, paths []string) (*Mask, error) {
+	m := &Mask{}
+	for _, expr := range regexes {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore regex %q: %w", expr, err)
+		}
+		m.regexps = append(m.regexps, re)
+	}
+	for _, pattern := range paths {
+		re, err := pathRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore path %q: %w", pattern, err)
+		}
+		m.paths = append(m.paths, re)
+	}
+	return m, nil
+}
+
+// HasPaths reports whether any path patterns were given; they only apply
+// to JSON comparisons.
+func (m *Mask) HasPaths() bool {
+	return m != nil && len(m.paths) > 0
+}
+
+// Text returns s with every ignore regex match replaced by Masked.
+func (m *Mask) Text(s string) string {
+	if m == nil {
+		return s
+	}
+	for _, re := range m.regexps {
+		s = re.ReplaceAllString(s, Masked)
+	}
+	return s
+}
+
+// IgnoresPath reports whether a JSON path, or one of its ancestors, matches
+// an ignore path pattern.
+func (m *Mask) IgnoresPath(path string) bool {
+	if m == nil {
+		return false
+	}
+	for _, re := range m.paths {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	keySegment   = `(?:\.[A-Za-z_$][A-Za-z0-9_$]*|\["(?:[^"\\]|\\.)*"\])`
+	indexSegment = `\[\d+\]`
+)
+
+func pathRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if !strings.HasPrefix(pattern, "$") {
+		pattern = "$." + strings.TrimPrefix(pattern, ".")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	rest := pattern
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[*]"):
+			b.WriteString(indexSegment)
+			rest = rest[3:]
+		case strings.HasPrefix(rest, ".*"):
+			b.WriteString(keySegment)
+			rest = rest[2:]
+		case strings.HasPrefix(rest, ".."):
+			// Any depth, then the next key
+			b.WriteString(`(?:` + keySegment + `|` + indexSegment + `)*\.`)
+			rest = rest[2:]
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+			rest = rest[1:]
+		}
+	}
+	// Match the path itself or anything below it
+	b.WriteString(`(?:$|[.\[])`)
+	return regexp.Compile(b.String())
+}
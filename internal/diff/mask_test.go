@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskIgnoresPath(t *testing.T) {
+	tests := []struct {
+		pattern       string
+		match, ignore []string
+	}{
+		{
+			pattern: "$.a",
+			match:   []string{"$.a", "$.a.b", "$.a[0]", `$.a["x y"]`},
+			ignore:  []string{"$.ab", "$.b.a", "$"},
+		},
+		{
+			pattern: "$.files[*].modified",
+			match:   []string{"$.files[0].modified", "$.files[12].modified.nanos"},
+			ignore:  []string{"$.files[0].modifiedAt", "$.files.modified", "$.other[0].modified", "$.files[0]"},
+		},
+		{
+			pattern: "$..created",
+			match:   []string{"$.created", "$.a.b[2].created", `$.a["x y"].created`},
+			ignore:  []string{"$.createdAt", "$.a.created_by"},
+		},
+		{
+			pattern: "$.*.id",
+			match:   []string{"$.a.id", `$["a b"].id`},
+			ignore:  []string{"$.a.b.id", "$[0].id", "$.id"},
+		},
+		{
+			// $. is implied
+			pattern: "files[*]",
+			match:   []string{"$.files[3]", "$.files[3].name"},
+			ignore:  []string{"$.files", "$.files.name"},
+		},
+		{
+			pattern: " .meta ",
+			match:   []string{"$.meta", "$.meta.took"},
+			ignore:  []string{"$.metadata"},
+		},
+		{
+			pattern: `$["a b"]`,
+			match:   []string{`$["a b"]`, `$["a b"].c`},
+			ignore:  []string{`$["a bc"]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			mask, err := NewMask(nil, []string{tt.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range tt.match {
+				if !mask.IgnoresPath(path) {
+					t.Errorf("%s not ignored", path)
+				}
+			}
+			for _, path := range tt.ignore {
+				if mask.IgnoresPath(path) {
+					t.Errorf("%s ignored", path)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskText(t *testing.T) {
+	mask, err := NewMask([]string{`\d+ms`, `[0-9a-f]{8}-[0-9a-f-]{27}`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := mask.Text("took 15ms, id 123e4567-e89b-12d3-a456-426614174000, took 7ms")
+	if want := "took <ignored>, id <ignored>, took <ignored>"; got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+	if mask.HasPaths() {
+		t.Error("HasPaths without path patterns")
+	}
+
+	var none *Mask
+	if none.Text("15ms") != "15ms" || none.IgnoresPath("$.a") || none.HasPaths() {
+		t.Error("a nil Mask ignored something")
+	}
+}
+
+func TestNewMaskErrors(t *testing.T) {
+	if _, err := NewMask([]string{"("}, nil); err == nil || !strings.Contains(err.Error(), `invalid ignore regex "("`) {
+		t.Errorf("error = %v, want the bad regex named", err)
+	}
+	mask, err := NewMask(nil, []string{"$.a"})
+	if err != nil || !mask.HasPaths() {
+		t.Errorf("NewMask with a path: %v, HasPaths %v", err, mask.HasPaths())
+	}
+}
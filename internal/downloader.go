@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,8 +11,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal/filelock"
 )
 
 func mapPlatform(goos, arch string) (string, error) {
@@ -33,26 +39,116 @@ func mapPlatform(goos, arch string) (string, error) {
 	return "", fmt.Errorf("unsupported platform: %s-%s", goos, arch)
 }
 
-func DownloadAndInstall(version string) error {
+// InstallOptions controls how a release binary is verified before install.
+type InstallOptions struct {
+	// Source is where the release is downloaded from. The zero value is
+	// resolved from JFVM_MIRROR / settings.json.
+	Source Source
+	// SignatureMode enables detached signature checks: "gpg" or "cosign".
+	SignatureMode string
+	// SignatureKey is the gpg keyring or cosign public key to verify against.
+	SignatureKey string
+	// Output receives progress messages. Defaults to stdout.
+	Output io.Writer
+}
+
+// DownloadAndInstall fetches a release into a staging directory under
+// ~/.jfvm, validates it and only then moves it into versions/. Nothing is left
+// behind in versions/ when any step fails.
+func DownloadAndInstall(version string, opts InstallOptions) error {
+	if err := ValidateVersion(version); err != nil {
+		return err
+	}
+
 	platform, err := mapPlatform(runtime.GOOS, runtime.GOARCH)
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("https://releases.jfrog.io/artifactory/jfrog-cli/v2-jf/%s/jfrog-cli-%s/jf", version, platform)
-	fmt.Printf("📥 Downloading from: %s\n", url)
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+
+	// Parallel installs of the same version (e.g. CI jobs sharing a home
+	// directory) wait for each other instead of downloading twice.
+	lock, err := filelock.Exclusive(filepath.Join(utils.JfvmLocks, version+".lock"))
+	if err != nil {
+		return fmt.Errorf("failed to acquire install lock: %w", err)
+	}
+	defer lock.Unlock()
+
+	if utils.CheckVersionExists(version) == nil {
+		fmt.Fprintf(out, "✅ Version %s was installed by another process\n", version)
+		return nil
+	}
+
+	src := opts.Source
+	if src.BaseURL == "" {
+		src, err = ResolveSource("")
+		if err != nil {
+			return err
+		}
+	}
+
+	url := src.BinaryURL(version, platform)
+	fmt.Fprintf(out, "📥 Downloading from: %s\n", url)
+
+	expected, err := fetchChecksum(src, url)
+	if err != nil {
+		return fmt.Errorf("cannot verify download: %w", err)
+	}
+
+	if err := os.MkdirAll(utils.JfvmStaging, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	stageDir, err := os.MkdirTemp(utils.JfvmStaging, version+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stageDir)
+	if err := os.Chmod(stageDir, 0755); err != nil {
+		return fmt.Errorf("failed to prepare staging directory: %w", err)
+	}
+
+	binPath := filepath.Join(stageDir, utils.BinaryName)
+	if err := downloadBinary(src, url, binPath, expected); err != nil {
+		return fmt.Errorf("refusing to install %s: %w", version, err)
+	}
+	fmt.Fprintf(out, "🔒 SHA-256 verified: %s\n", expected)
+
+	if opts.SignatureMode != SignatureNone {
+		if err := verifySignature(src, opts.SignatureMode, opts.SignatureKey, url, binPath); err != nil {
+			return fmt.Errorf("refusing to install %s: %w", version, err)
+		}
+		fmt.Fprintf(out, "🔏 %s signature verified\n", opts.SignatureMode)
+	}
+
+	if err := os.Chmod(binPath, 0755); err != nil {
+		return fmt.Errorf("chmod failed: %w", err)
+	}
+
+	if runtime.GOOS == "darwin" {
+		_ = exec.Command("xattr", "-c", binPath).Run()
+	}
 
-	dir := filepath.Join(utils.JfvmVersions, version)
-	os.MkdirAll(dir, 0755)
-	binPath := filepath.Join(dir, utils.BinaryName)
+	if err := validateBinary(binPath, version); err != nil {
+		return fmt.Errorf("refusing to install %s: %w", version, err)
+	}
 
-	out, err := os.Create(binPath)
+	return promote(stageDir, filepath.Join(utils.JfvmVersions, version))
+}
+
+// downloadBinary streams url into path and checks the transfer is complete
+// and matches the expected SHA-256.
+func downloadBinary(src Source, url, path, expected string) error {
+	out, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create binary file: %w", err)
 	}
 	defer out.Close()
 
-	resp, err := http.Get(url)
+	resp, err := src.Get(url)
 	if err != nil {
 		return fmt.Errorf("http request failed: %w", err)
 	}
@@ -62,18 +158,77 @@ func DownloadAndInstall(version string) error {
 		return fmt.Errorf("failed to download: %s", resp.Status)
 	}
 
-	_, err = io.Copy(out, resp.Body)
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(out, hasher), resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to write binary: %w", err)
 	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
 
-	if err := os.Chmod(binPath, 0755); err != nil {
-		return fmt.Errorf("chmod failed: %w", err)
+	if written == 0 {
+		return fmt.Errorf("downloaded binary is empty")
+	}
+	if resp.ContentLength > 0 && written != resp.ContentLength {
+		return fmt.Errorf("incomplete download: got %d of %d bytes", written, resp.ContentLength)
 	}
 
-	if runtime.GOOS == "darwin" {
-		_ = exec.Command("xattr", "-c", binPath).Run()
+	return verifyChecksum(expected, hex.EncodeToString(hasher.Sum(nil)))
+}
+
+// validateBinary runs the staged binary and makes sure it reports the
+// version we asked for.
+func validateBinary(binPath, version string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, binPath, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("'jf --version' failed: %w", err)
+	}
+	if !reportsVersion(string(out), version) {
+		return fmt.Errorf("binary reports %q, expected version %s", strings.TrimSpace(string(out)), version)
+	}
+	return nil
+}
+
+// reportsVersion reports whether version appears as a whole word in the
+// output of `jf --version`, so 2.74.1 doesn't match "jf version 2.74.10".
+func reportsVersion(output, version string) bool {
+	want := strings.TrimPrefix(version, "v")
+	for _, field := range strings.Fields(output) {
+		if strings.TrimPrefix(field, "v") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// promote moves a validated staging directory into place. An existing
+// version directory is moved aside first and restored if the swap fails.
+func promote(stageDir, targetDir string) error {
+	if err := os.MkdirAll(filepath.Dir(targetDir), 0755); err != nil {
+		return fmt.Errorf("failed to create versions directory: %w", err)
+	}
+
+	backupDir := ""
+	if _, err := os.Stat(targetDir); err == nil {
+		backupDir = stageDir + ".previous"
+		if err := os.Rename(targetDir, backupDir); err != nil {
+			return fmt.Errorf("failed to move existing install aside: %w", err)
+		}
 	}
 
+	if err := os.Rename(stageDir, targetDir); err != nil {
+		if backupDir != "" {
+			_ = os.Rename(backupDir, targetDir)
+		}
+		return fmt.Errorf("failed to move install into place: %w", err)
+	}
+
+	if backupDir != "" {
+		_ = os.RemoveAll(backupDir)
+	}
 	return nil
 }
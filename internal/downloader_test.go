@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+const testVersion = "2.74.0"
+
+// fakeBinary is a jf stand-in that passes validateBinary.
+var fakeBinary = []byte("#!/bin/sh\necho \"jf version " + testVersion + "\"\n")
+
+// releaseServer serves fakeBinary at the v2 layout path and, unless
+// checksum is empty, the given checksum file next to it.
+func releaseServer(t *testing.T, checksum string) (Source, *int) {
+	t.Helper()
+	platform, err := mapPlatform(runtime.GOOS, runtime.GOARCH)
+	if err != nil || runtime.GOOS == "windows" {
+		t.Skipf("fake release binaries are shell scripts; unsupported on %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	binaryRequests := 0
+	binaryPath := "/" + strings.NewReplacer("{version}", testVersion, "{platform}", platform).Replace(LayoutV2)
+	mux := http.NewServeMux()
+	mux.HandleFunc(binaryPath, func(w http.ResponseWriter, r *http.Request) {
+		binaryRequests++
+		w.Write(fakeBinary)
+	})
+	if checksum != "" {
+		mux.HandleFunc(binaryPath+".sha256", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, checksum)
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	src, err := Source{BaseURL: server.URL}.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, &binaryRequests
+}
+
+// useTempRoot points the jfvm directories at a temporary root.
+func useTempRoot(t *testing.T) {
+	t.Helper()
+	root := t.TempDir()
+	saved := []string{utils.JfvmVersions, utils.JfvmStaging, utils.JfvmLocks}
+	utils.JfvmVersions = filepath.Join(root, utils.VersionsDir)
+	utils.JfvmStaging = filepath.Join(root, utils.StagingDir)
+	utils.JfvmLocks = filepath.Join(root, utils.LocksDir)
+	t.Cleanup(func() {
+		utils.JfvmVersions, utils.JfvmStaging, utils.JfvmLocks = saved[0], saved[1], saved[2]
+	})
+}
+
+func install(src Source) error {
+	return DownloadAndInstall(testVersion, InstallOptions{Source: src, Output: io.Discard})
+}
+
+// assertNothingInstalled checks a failed install left no version and no
+// staged files behind.
+func assertNothingInstalled(t *testing.T) {
+	t.Helper()
+	if _, err := os.Stat(filepath.Join(utils.JfvmVersions, testVersion)); !os.IsNotExist(err) {
+		t.Errorf("version directory exists after a failed install (stat err: %v)", err)
+	}
+	staged, _ := os.ReadDir(utils.JfvmStaging)
+	if len(staged) != 0 {
+		t.Errorf("staging directory not cleaned up: %v", staged)
+	}
+}
+
+func TestDownloadAndInstallMatchingChecksum(t *testing.T) {
+	useTempRoot(t)
+	sum := sha256.Sum256(fakeBinary)
+	src, _ := releaseServer(t, hex.EncodeToString(sum[:])+"  jf\n")
+
+	if err := install(src); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(utils.JfvmVersions, testVersion, utils.BinaryName))
+	if err != nil {
+		t.Fatalf("installed binary missing: %v", err)
+	}
+	if string(got) != string(fakeBinary) {
+		t.Errorf("installed binary = %q, want %q", got, fakeBinary)
+	}
+	staged, _ := os.ReadDir(utils.JfvmStaging)
+	if len(staged) != 0 {
+		t.Errorf("staging directory not cleaned up: %v", staged)
+	}
+}
+
+func TestDownloadAndInstallChecksumMismatch(t *testing.T) {
+	useTempRoot(t)
+	src, _ := releaseServer(t, strings.Repeat("0", 64))
+
+	err := install(src)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("install error = %v, want a checksum mismatch", err)
+	}
+	assertNothingInstalled(t)
+}
+
+func TestDownloadAndInstallMissingChecksum(t *testing.T) {
+	useTempRoot(t)
+	src, binaryRequests := releaseServer(t, "")
+
+	err := install(src)
+	if err == nil || !strings.Contains(err.Error(), "cannot verify download") {
+		t.Fatalf("install error = %v, want a missing checksum error", err)
+	}
+	if *binaryRequests != 0 {
+		t.Errorf("binary downloaded %d times without a checksum", *binaryRequests)
+	}
+	assertNothingInstalled(t)
+}
+
+func TestParseChecksum(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{"bare digest", digest + "\n", digest, false},
+		{"digest and filename", strings.ToUpper(digest) + "  jf", digest, false},
+		{"empty", "  \n", "", true},
+		{"too short", "abcd", "", true},
+		{"not hex", strings.Repeat("zz", 32), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum(tt.data)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("parseChecksum(%q) = %q, %v; want %q, error %v", tt.data, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadAndInstallRejectsUnsafeVersions(t *testing.T) {
+	useTempRoot(t)
+	for _, version := range []string{"../../evil", "2.74.0/../../evil", "2.74.0-../../x", "latest", "", `2.74.0\..\x`} {
+		err := DownloadAndInstall(version, InstallOptions{Source: Source{BaseURL: "http://127.0.0.1:1"}, Output: io.Discard})
+		if err == nil || !strings.Contains(err.Error(), "invalid version") {
+			t.Errorf("DownloadAndInstall(%q) error = %v, want an invalid version error", version, err)
+		}
+	}
+	if _, err := os.Stat(utils.JfvmLocks); !os.IsNotExist(err) {
+		t.Errorf("locks directory created for an invalid version (stat err: %v)", err)
+	}
+}
+
+func TestReportsVersion(t *testing.T) {
+	tests := []struct {
+		output, version string
+		want            bool
+	}{
+		{"jf version 2.74.1\n", "2.74.1", true},
+		{"jf version 2.74.1\n", "v2.74.1", true},
+		{"jf version v2.74.1", "2.74.1", true},
+		{"jf version 2.74.10\n", "2.74.1", false},
+		{"jf version 2.74.0\n", "2.74", false},
+		{"jf version 12.74.1\n", "2.74.1", false},
+		{"", "2.74.1", false},
+	}
+	for _, tt := range tests {
+		if got := reportsVersion(tt.output, tt.version); got != tt.want {
+			t.Errorf("reportsVersion(%q, %q) = %v, want %v", tt.output, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadAndInstallRejectsWrongVersionBinary(t *testing.T) {
+	useTempRoot(t)
+	saved := fakeBinary
+	fakeBinary = []byte("#!/bin/sh\necho \"jf version " + testVersion + "0\"\n")
+	t.Cleanup(func() { fakeBinary = saved })
+	sum := sha256.Sum256(fakeBinary)
+	src, _ := releaseServer(t, hex.EncodeToString(sum[:]))
+
+	err := install(src)
+	if err == nil || !strings.Contains(err.Error(), "expected version") {
+		t.Fatalf("install error = %v, want a version mismatch", err)
+	}
+	assertNothingInstalled(t)
+}
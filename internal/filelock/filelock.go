@@ -0,0 +1,49 @@
+// Package filelock provides advisory locks on files that are shared between
+// processes, e.g. parallel shims or CI jobs writing the same jfvm state.
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Lock is a held lock on a file. It is released by Unlock or when the
+// process exits.
+type Lock struct {
+	f *os.File
+}
+
+// Exclusive blocks until an exclusive lock on path is held. The lock file and
+// its directory are created if needed.
+func Exclusive(path string) (*Lock, error) {
+	return acquire(path, true)
+}
+
+// Shared blocks until a shared (read) lock on path is held.
+func Shared(path string) (*Lock, error) {
+	return acquire(path, false)
+}
+
+func acquire(path string, exclusive bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
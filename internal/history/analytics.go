@@ -0,0 +1,367 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bhanurp/jfvm/internal/stats"
+)
+
+// Regression thresholds: a command regressed after a version switch when
+// both versions ran it at least regressionMinSamples times and its median
+// got slower by regressionSlowdown (and at least regressionMinSlowdownMs),
+// or its failure rate rose by regressionFailureDelta.
+const (
+	regressionMinSamples    = 3
+	regressionSlowdown      = 0.20
+	regressionMinSlowdownMs = 50
+	regressionFailureDelta  = 0.10
+
+	topCommandsInReport = 10
+	weekLayout          = "2006-01-02"
+)
+
+// Latency holds duration percentiles in milliseconds.
+type Latency struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+}
+
+// VersionReport summarizes one jf version.
+type VersionReport struct {
+	Version     string    `json:"version"`
+	Count       int       `json:"count"`
+	Failures    int       `json:"failures"`
+	FailureRate float64   `json:"failure_rate"`
+	TotalTimeMs int64     `json:"total_time_ms"`
+	FirstUsed   time.Time `json:"first_used"`
+	LastUsed    time.Time `json:"last_used"`
+	Latency     Latency   `json:"latency"`
+}
+
+// CommandReport summarizes one command (see CommandName) on one version.
+type CommandReport struct {
+	Command     string  `json:"command"`
+	Version     string  `json:"version"`
+	Count       int     `json:"count"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	Latency     Latency `json:"latency"`
+}
+
+// WeekReport summarizes one ISO week, with changes relative to the
+// previous week that had any runs.
+type WeekReport struct {
+	Week              string  `json:"week"`
+	Start             string  `json:"start"`
+	Count             int     `json:"count"`
+	Failures          int     `json:"failures"`
+	FailureRate       float64 `json:"failure_rate"`
+	MedianMs          float64 `json:"median_ms"`
+	CountChange       float64 `json:"count_change"`
+	MedianChange      float64 `json:"median_change"`
+	FailureRateChange float64 `json:"failure_rate_change"`
+}
+
+// Regression is a command that got slower or less reliable after switching
+// from one version to the next.
+type Regression struct {
+	Command           string    `json:"command"`
+	From              string    `json:"from"`
+	To                string    `json:"to"`
+	SwitchedAt        time.Time `json:"switched_at"`
+	MedianBeforeMs    float64   `json:"median_before_ms"`
+	MedianAfterMs     float64   `json:"median_after_ms"`
+	FailureRateBefore float64   `json:"failure_rate_before"`
+	FailureRateAfter  float64   `json:"failure_rate_after"`
+	Reasons           []string  `json:"reasons"`
+}
+
+// CommandCount is how often an exact command line was run.
+type CommandCount struct {
+	Command string `json:"command"`
+	Count   int    `json:"count"`
+}
+
+// Report is the analytics behind `jfvm history --stats`.
+type Report struct {
+	Total       int             `json:"total"`
+	Failures    int             `json:"failures"`
+	FirstUsed   time.Time       `json:"first_used"`
+	LastUsed    time.Time       `json:"last_used"`
+	Versions    []VersionReport `json:"versions"`
+	Commands    []CommandReport `json:"commands"`
+	TopCommands []CommandCount  `json:"top_commands"`
+	Weeks       []WeekReport    `json:"weeks"`
+	Regressions []Regression    `json:"regressions"`
+}
+
+// runs accumulates entries for one group.
+type runs struct {
+	count, failures int
+	totalMs         int64
+	durations       []float64
+	first, last     time.Time
+}
+
+func (r *runs) add(e Entry) {
+	if r.count == 0 || e.Timestamp.Before(r.first) {
+		r.first = e.Timestamp
+	}
+	if e.Timestamp.After(r.last) {
+		r.last = e.Timestamp
+	}
+	r.count++
+	if e.ExitCode != 0 {
+		r.failures++
+	}
+	r.totalMs += e.Duration
+	// Entries from older versions may lack a duration; leave them out of
+	// latency figures rather than counting them as instant.
+	if e.Duration > 0 {
+		r.durations = append(r.durations, float64(e.Duration))
+	}
+}
+
+func (r *runs) failureRate() float64 {
+	return stats.Ratio(r.failures, r.count)
+}
+
+func (r *runs) latency() Latency {
+	sorted := stats.Sorted(r.durations)
+	return Latency{
+		P50: stats.Percentile(sorted, 50),
+		P90: stats.Percentile(sorted, 90),
+		P99: stats.Percentile(sorted, 99),
+	}
+}
+
+// group returns the runs for key, adding an empty group on first use.
+func group[K comparable](m map[K]*runs, key K) *runs {
+	if m[key] == nil {
+		m[key] = &runs{}
+	}
+	return m[key]
+}
+
+// Analyze builds the usage report for entries.
+func Analyze(entries []Entry) Report {
+	report := Report{
+		Versions:    []VersionReport{},
+		Commands:    []CommandReport{},
+		TopCommands: []CommandCount{},
+		Weeks:       []WeekReport{},
+		Regressions: []Regression{},
+	}
+	if len(entries) == 0 {
+		return report
+	}
+
+	var all runs
+	byVersion := make(map[string]*runs)
+	byCommand := make(map[[2]string]*runs)
+	byWeek := make(map[string]*runs)
+	weekStart := make(map[string]time.Time)
+	lines := make(map[string]int)
+
+	for _, e := range entries {
+		all.add(e)
+		group(byVersion, e.Version).add(e)
+
+		group(byCommand, [2]string{CommandName(e.Command), e.Version}).add(e)
+
+		week, start := isoWeek(e.Timestamp)
+		group(byWeek, week).add(e)
+		weekStart[week] = start
+
+		if e.Command != "" {
+			lines[e.Command]++
+		}
+	}
+
+	report.Total = all.count
+	report.Failures = all.failures
+	report.FirstUsed = all.first
+	report.LastUsed = all.last
+
+	for version, r := range byVersion {
+		report.Versions = append(report.Versions, VersionReport{
+			Version:     version,
+			Count:       r.count,
+			Failures:    r.failures,
+			FailureRate: r.failureRate(),
+			TotalTimeMs: r.totalMs,
+			FirstUsed:   r.first,
+			LastUsed:    r.last,
+			Latency:     r.latency(),
+		})
+	}
+	sort.Slice(report.Versions, func(i, j int) bool {
+		a, b := report.Versions[i], report.Versions[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Version < b.Version
+	})
+
+	for key, r := range byCommand {
+		report.Commands = append(report.Commands, CommandReport{
+			Command:     key[0],
+			Version:     key[1],
+			Count:       r.count,
+			Failures:    r.failures,
+			FailureRate: r.failureRate(),
+			Latency:     r.latency(),
+		})
+	}
+	sort.Slice(report.Commands, func(i, j int) bool {
+		a, b := report.Commands[i], report.Commands[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Command != b.Command {
+			return a.Command < b.Command
+		}
+		return a.Version < b.Version
+	})
+
+	for line, count := range lines {
+		report.TopCommands = append(report.TopCommands, CommandCount{line, count})
+	}
+	sort.Slice(report.TopCommands, func(i, j int) bool {
+		a, b := report.TopCommands[i], report.TopCommands[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Command < b.Command
+	})
+	if len(report.TopCommands) > topCommandsInReport {
+		report.TopCommands = report.TopCommands[:topCommandsInReport]
+	}
+
+	report.Weeks = weeklyTrends(byWeek, weekStart)
+	report.Regressions = regressions(byCommand)
+
+	return report
+}
+
+func weeklyTrends(byWeek map[string]*runs, weekStart map[string]time.Time) []WeekReport {
+	weeks := make([]string, 0, len(byWeek))
+	for week := range byWeek {
+		weeks = append(weeks, week)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weekStart[weeks[i]].Before(weekStart[weeks[j]]) })
+
+	reports := []WeekReport{}
+	for i, week := range weeks {
+		r := byWeek[week]
+		report := WeekReport{
+			Week:        week,
+			Start:       weekStart[week].Format(weekLayout),
+			Count:       r.count,
+			Failures:    r.failures,
+			FailureRate: r.failureRate(),
+			MedianMs:    stats.Median(stats.Sorted(r.durations)),
+		}
+		if i > 0 {
+			prev := reports[i-1]
+			report.CountChange = change(float64(prev.Count), float64(report.Count))
+			report.MedianChange = change(prev.MedianMs, report.MedianMs)
+			report.FailureRateChange = report.FailureRate - prev.FailureRate
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// regressions compares, for every command, each version with the version
+// that command ran on before it, in order of first use.
+func regressions(byCommand map[[2]string]*runs) []Regression {
+	versionsOf := make(map[string][]string)
+	for key := range byCommand {
+		versionsOf[key[0]] = append(versionsOf[key[0]], key[1])
+	}
+
+	found := []Regression{}
+	for command, versions := range versionsOf {
+		if len(versions) < 2 {
+			continue
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return byCommand[[2]string{command, versions[i]}].first.Before(byCommand[[2]string{command, versions[j]}].first)
+		})
+
+		for i := 1; i < len(versions); i++ {
+			before := byCommand[[2]string{command, versions[i-1]}]
+			after := byCommand[[2]string{command, versions[i]}]
+			if before.count < regressionMinSamples || after.count < regressionMinSamples {
+				continue
+			}
+
+			r := Regression{
+				Command:           command,
+				From:              versions[i-1],
+				To:                versions[i],
+				SwitchedAt:        after.first,
+				MedianBeforeMs:    stats.Median(stats.Sorted(before.durations)),
+				MedianAfterMs:     stats.Median(stats.Sorted(after.durations)),
+				FailureRateBefore: before.failureRate(),
+				FailureRateAfter:  after.failureRate(),
+			}
+			if len(before.durations) >= regressionMinSamples && len(after.durations) >= regressionMinSamples &&
+				r.MedianAfterMs-r.MedianBeforeMs >= regressionMinSlowdownMs &&
+				change(r.MedianBeforeMs, r.MedianAfterMs) >= regressionSlowdown {
+				r.Reasons = append(r.Reasons, fmt.Sprintf("median %+.0f%%", change(r.MedianBeforeMs, r.MedianAfterMs)*100))
+			}
+			if r.FailureRateAfter-r.FailureRateBefore >= regressionFailureDelta {
+				r.Reasons = append(r.Reasons, fmt.Sprintf("failure rate %+.0f pts", (r.FailureRateAfter-r.FailureRateBefore)*100))
+			}
+			if len(r.Reasons) > 0 {
+				found = append(found, r)
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].SwitchedAt.Equal(found[j].SwitchedAt) {
+			return found[i].SwitchedAt.After(found[j].SwitchedAt)
+		}
+		return found[i].Command < found[j].Command
+	})
+	return found
+}
+
+// change is the relative change from before to after, 0 when before is 0.
+func change(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) / before
+}
+
+// isoWeek returns the ISO week label (2026-W07) and the local Monday that
+// starts it.
+func isoWeek(t time.Time) (string, time.Time) {
+	t = t.Local()
+	year, week := t.ISOWeek()
+	offset := (int(t.Weekday()) + 6) % 7
+	monday := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+	return fmt.Sprintf("%d-W%02d", year, week), monday
+}
+
+// CommandName reduces a command line to its subcommand words ("rt upload")
+// so runs with different arguments group together. It stops at the first
+// flag or argument that looks like a path, pattern or key=value.
+func CommandName(command string) string {
+	var words []string
+	for _, field := range strings.Fields(command) {
+		if strings.HasPrefix(field, "-") || strings.ContainsAny(field, "/\\.=:*") || len(words) == 2 {
+			break
+		}
+		words = append(words, field)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,263 @@
+package history
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// runsOf returns one entry per duration, an hour apart from start, with the
+// first failures entries failing.
+func runsOf(command, version string, start time.Time, durations []int64, failures int) []Entry {
+	var entries []Entry
+	for i, d := range durations {
+		e := Entry{Version: version, Command: command, Timestamp: start.Add(time.Duration(i) * time.Hour), Duration: d}
+		if i < failures {
+			e.ExitCode = 1
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func near(got, want float64) bool {
+	return math.Abs(got-want) < 1e-9
+}
+
+func TestAnalyze(t *testing.T) {
+	// Monday noon of ISO weeks 2024-W02 and 2024-W03
+	week2 := time.Date(2024, 1, 8, 12, 0, 0, 0, time.Local)
+	week3 := time.Date(2024, 1, 15, 12, 0, 0, 0, time.Local)
+
+	var entries []Entry
+	entries = append(entries, runsOf("rt ping", "2.74.0", week2, []int64{100, 200, 300, 400}, 0)...)
+	// An entry without a duration counts as a run but not towards latency
+	entries = append(entries, runsOf("rt upload a.zip repo/", "2.74.0", week2.Add(24*time.Hour), []int64{1000, 1000, 1000, 0}, 0)...)
+	entries = append(entries, runsOf("rt ping", "2.75.0", week3, []int64{200, 300, 400, 500}, 1)...)
+	entries = append(entries, runsOf("rt upload b.zip repo/", "2.75.0", week3.Add(24*time.Hour), []int64{1010, 1010, 1010}, 0)...)
+
+	report := Analyze(entries)
+
+	if report.Total != 15 || report.Failures != 1 {
+		t.Errorf("total = %d, failures = %d; want 15 and 1", report.Total, report.Failures)
+	}
+	if !report.FirstUsed.Equal(week2) || !report.LastUsed.Equal(week3.Add(26*time.Hour)) {
+		t.Errorf("first/last used = %v/%v", report.FirstUsed, report.LastUsed)
+	}
+
+	t.Run("versions", func(t *testing.T) {
+		if len(report.Versions) != 2 {
+			t.Fatalf("got %d versions, want 2", len(report.Versions))
+		}
+		v := report.Versions[0]
+		if v.Version != "2.74.0" || v.Count != 8 || v.Failures != 0 || v.TotalTimeMs != 4000 {
+			t.Errorf("first version = %+v, want 2.74.0 with 8 runs and 4000ms", v)
+		}
+		// Durations 100 200 300 400 1000 1000 1000
+		if want := (Latency{P50: 400, P90: 1000, P99: 1000}); v.Latency != want {
+			t.Errorf("2.74.0 latency = %+v, want %+v", v.Latency, want)
+		}
+		if v := report.Versions[1]; v.Version != "2.75.0" || v.Count != 7 || !near(v.FailureRate, 1.0/7) {
+			t.Errorf("second version = %+v, want 2.75.0 with 7 runs failing 1/7", v)
+		}
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		var ping *CommandReport
+		for i, c := range report.Commands {
+			if c.Command == "rt ping" && c.Version == "2.74.0" {
+				ping = &report.Commands[i]
+			}
+		}
+		if ping == nil {
+			t.Fatalf("no rt ping on 2.74.0 in %+v", report.Commands)
+		}
+		// Linear interpolation between the closest ranks
+		if want := (Latency{P50: 250, P90: 370, P99: 397}); !near(ping.Latency.P50, want.P50) ||
+			!near(ping.Latency.P90, want.P90) || !near(ping.Latency.P99, want.P99) {
+			t.Errorf("rt ping latency = %+v, want %+v", ping.Latency, want)
+		}
+		first := report.Commands[0]
+		if first.Command != "rt ping" || first.Version != "2.74.0" || first.Count != 4 {
+			t.Errorf("commands not ordered by count, then name, then version: first is %+v", first)
+		}
+		if len(report.Commands) != 4 {
+			t.Errorf("got %d command groups, want rt ping and rt upload on two versions", len(report.Commands))
+		}
+	})
+
+	t.Run("top commands", func(t *testing.T) {
+		want := []CommandCount{{"rt ping", 8}, {"rt upload a.zip repo/", 4}, {"rt upload b.zip repo/", 3}}
+		if !reflect.DeepEqual(report.TopCommands, want) {
+			t.Errorf("top commands = %+v, want %+v", report.TopCommands, want)
+		}
+	})
+
+	t.Run("weeks", func(t *testing.T) {
+		if len(report.Weeks) != 2 {
+			t.Fatalf("got %d weeks, want 2", len(report.Weeks))
+		}
+		w2, w3 := report.Weeks[0], report.Weeks[1]
+		if w2.Week != "2024-W02" || w2.Start != "2024-01-08" || w2.Count != 8 || w2.MedianMs != 400 {
+			t.Errorf("first week = %+v", w2)
+		}
+		if w2.CountChange != 0 || w2.MedianChange != 0 || w2.FailureRateChange != 0 {
+			t.Errorf("first week has changes %+v, want none", w2)
+		}
+		// Durations 200 300 400 500 1010 1010 1010
+		if w3.Week != "2024-W03" || w3.Count != 7 || w3.MedianMs != 500 {
+			t.Errorf("second week = %+v", w3)
+		}
+		if !near(w3.CountChange, -0.125) || !near(w3.MedianChange, 0.25) || !near(w3.FailureRateChange, 1.0/7) {
+			t.Errorf("second week changes = %v/%v/%v, want -0.125/0.25/%v", w3.CountChange, w3.MedianChange, w3.FailureRateChange, 1.0/7)
+		}
+	})
+
+	t.Run("regressions", func(t *testing.T) {
+		want := []Regression{{
+			Command:           "rt ping",
+			From:              "2.74.0",
+			To:                "2.75.0",
+			SwitchedAt:        week3,
+			MedianBeforeMs:    250,
+			MedianAfterMs:     350,
+			FailureRateBefore: 0,
+			FailureRateAfter:  0.25,
+			Reasons:           []string{"median +40%", "failure rate +25 pts"},
+		}}
+		if !reflect.DeepEqual(report.Regressions, want) {
+			t.Errorf("regressions = %+v\nwant %+v", report.Regressions, want)
+		}
+	})
+}
+
+func TestAnalyzeWithoutEntries(t *testing.T) {
+	report := Analyze(nil)
+	if report.Total != 0 || report.Versions == nil || report.Weeks == nil || report.Regressions == nil {
+		t.Errorf("empty report = %+v, want zero counts and empty (not nil) lists", report)
+	}
+}
+
+func TestAnalyzeRegressions(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	later := start.Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		before, after []Entry
+		want          []string
+	}{
+		{
+			name:   "slower",
+			before: runsOf("rt ping", "2.74.0", start, []int64{100, 100, 100}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{200, 200, 200}, 0),
+			want:   []string{"median +100%"},
+		},
+		{
+			name:   "slower by under 50ms",
+			before: runsOf("rt ping", "2.74.0", start, []int64{100, 100, 100}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{140, 140, 140}, 0),
+		},
+		{
+			name:   "slower by under 20%",
+			before: runsOf("rt ping", "2.74.0", start, []int64{1000, 1000, 1000}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{1150, 1150, 1150}, 0),
+		},
+		{
+			name:   "faster",
+			before: runsOf("rt ping", "2.74.0", start, []int64{500, 500, 500}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{100, 100, 100}, 0),
+		},
+		{
+			name:   "too few runs before",
+			before: runsOf("rt ping", "2.74.0", start, []int64{100, 100}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{900, 900, 900}, 3),
+		},
+		{
+			name:   "more failures",
+			before: runsOf("rt ping", "2.74.0", start, []int64{100, 100, 100}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{100, 100, 100}, 1),
+			want:   []string{"failure rate +33 pts"},
+		},
+		{
+			name:   "durations not recorded",
+			before: runsOf("rt ping", "2.74.0", start, []int64{0, 0, 0}, 0),
+			after:  runsOf("rt ping", "2.75.0", later, []int64{900, 900, 900}, 0),
+		},
+		{
+			name:   "different commands",
+			before: runsOf("rt ping", "2.74.0", start, []int64{100, 100, 100}, 0),
+			after:  runsOf("rt search", "2.75.0", later, []int64{900, 900, 900}, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := Analyze(append(tt.before, tt.after...)).Regressions
+			if tt.want == nil {
+				if len(found) != 0 {
+					t.Errorf("regressions = %+v, want none", found)
+				}
+				return
+			}
+			if len(found) != 1 || !reflect.DeepEqual(found[0].Reasons, tt.want) {
+				t.Fatalf("regressions = %+v, want one with reasons %q", found, tt.want)
+			}
+			if r := found[0]; r.From != "2.74.0" || r.To != "2.75.0" || !r.SwitchedAt.Equal(later) {
+				t.Errorf("regression %s→%s at %v, want 2.74.0→2.75.0 at %v", r.From, r.To, r.SwitchedAt, later)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRegressionsFollowFirstUse(t *testing.T) {
+	// A downgrade: 2.75.0 was used first, so going back to 2.74.0 is the
+	// switch that slowed rt ping down
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	entries := runsOf("rt ping", "2.75.0", start, []int64{100, 100, 100}, 0)
+	entries = append(entries, runsOf("rt ping", "2.74.0", start.Add(24*time.Hour), []int64{300, 300, 300}, 0)...)
+
+	found := Analyze(entries).Regressions
+	if len(found) != 1 || found[0].From != "2.75.0" || found[0].To != "2.74.0" {
+		t.Errorf("regressions = %+v, want 2.75.0→2.74.0", found)
+	}
+}
+
+func TestISOWeek(t *testing.T) {
+	tests := []struct {
+		date       time.Time
+		week, from string
+	}{
+		{time.Date(2024, 1, 8, 0, 0, 0, 0, time.Local), "2024-W02", "2024-01-08"},
+		{time.Date(2024, 1, 14, 23, 59, 0, 0, time.Local), "2024-W02", "2024-01-08"},
+		// Week 1 of 2025 starts in December 2024
+		{time.Date(2024, 12, 30, 12, 0, 0, 0, time.Local), "2025-W01", "2024-12-30"},
+		{time.Date(2025, 1, 5, 12, 0, 0, 0, time.Local), "2025-W01", "2024-12-30"},
+		// ...and 1 January 2021 belongs to the last week of 2020
+		{time.Date(2021, 1, 1, 12, 0, 0, 0, time.Local), "2020-W53", "2020-12-28"},
+	}
+	for _, tt := range tests {
+		week, start := isoWeek(tt.date)
+		if week != tt.week || start.Format(weekLayout) != tt.from {
+			t.Errorf("isoWeek(%s) = %s starting %s, want %s starting %s",
+				tt.date.Format(time.DateTime), week, start.Format(weekLayout), tt.week, tt.from)
+		}
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"rt ping":                          "rt ping",
+		"rt upload a.zip repo/":            "rt upload",
+		"rt u --flat build/*.zip repo/":    "rt u",
+		"config show":                      "config show",
+		"--version":                        "",
+		"rt build-publish my-build 12 key": "rt build-publish",
+		"":                                 "",
+	}
+	for command, want := range tests {
+		if got := CommandName(command); got != want {
+			t.Errorf("CommandName(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
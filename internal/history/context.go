@@ -0,0 +1,93 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// jfrogEnvPrefix selects the environment variables jf reads. Only their
+// names are recorded; values often hold credentials.
+const jfrogEnvPrefix = "JFROG_CLI_"
+
+// CaptureContext fills in where and in what environment jf ran: working
+// directory, hostname, JFROG_CLI_* variable names and the server ID the
+// command targets. args are the arguments passed to jf.
+func (e *Entry) CaptureContext(args []string) {
+	if e.Cwd == "" {
+		e.Cwd, _ = os.Getwd()
+	}
+	e.Hostname, _ = os.Hostname()
+	e.Env = jfrogEnvNames()
+	e.ServerID = serverID(args)
+}
+
+func jfrogEnvNames() []string {
+	var names []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, jfrogEnvPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// serverID returns the --server-id passed to jf, or else the default server
+// from the JFrog CLI config.
+func serverID(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--server-id="); ok {
+			return v
+		}
+		if arg == "--server-id" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return defaultServerID()
+}
+
+// defaultServerID reads the newest jfrog-cli.conf.v<N> under
+// JFROG_CLI_HOME_DIR (default ~/.jfrog).
+func defaultServerID() string {
+	matches, _ := filepath.Glob(filepath.Join(utils.JFrogCLIHome(), "jfrog-cli.conf.v*"))
+	newest, newestVersion := "", -1
+	for _, path := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "jfrog-cli.conf.v"))
+		if err == nil && n > newestVersion {
+			newest, newestVersion = path, n
+		}
+	}
+	if newest == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(newest)
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		Servers []struct {
+			ServerID  string `json:"serverId"`
+			IsDefault bool   `json:"isDefault"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+	for _, server := range config.Servers {
+		if server.IsDefault {
+			return server.ServerID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,243 @@
+package history
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// SchemaVersion is stored with every entry so readers can upgrade older
+// records. Entries without it were migrated from history.json (schema 1).
+const SchemaVersion = 2
+
+// MaxOutputSize bounds the stdout and stderr kept per entry.
+const MaxOutputSize = 5000
+
+const truncatedMarker = "... (truncated)\n"
+
+// Entry is one recorded jf invocation. The JSON field names are the on-disk
+// format and must stay stable.
+type Entry struct {
+	Schema    int       `json:"schema,omitempty"`
+	ID        string    `json:"id,omitempty"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command,omitempty"`
+	Duration  int64     `json:"duration_ms,omitempty"`
+	ExitCode  int       `json:"exit_code,omitempty"`
+	Stdout    string    `json:"stdout,omitempty"`
+	Stderr    string    `json:"stderr,omitempty"`
+	// StdoutCaptured and StderrCaptured tell "printed nothing" apart from
+	// "not recorded": a stream is not captured when it was a terminal,
+	// output capture is off, or the command is never captured.
+	StdoutCaptured bool `json:"stdout_captured,omitempty"`
+	StderrCaptured bool `json:"stderr_captured,omitempty"`
+	// Args are the exact jf arguments, kept only when redaction left the
+	// command untouched so replays never run with masked values.
+	Args []string `json:"args,omitempty"`
+
+	// Context, see CaptureContext. Source is what selected the version
+	// (JFVM_VERSION, a .jfrog-version path or the global config) and Spec the
+	// requested alias, channel or range when it differs from Version. Env
+	// holds JFROG_CLI_* variable names only.
+	Cwd      string   `json:"cwd,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Spec     string   `json:"spec,omitempty"`
+	Hostname string   `json:"hostname,omitempty"`
+	Env      []string `json:"env,omitempty"`
+	ServerID string   `json:"server_id,omitempty"`
+}
+
+// Dir is where history is stored.
+func Dir() string {
+	return utils.JfvmRoot
+}
+
+// Record stamps, redacts and appends an entry to the history log, compacting
+// the log once it outgrows the retention policy.
+func Record(entry Entry) error {
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		return err
+	}
+	return RecordWith(entry, settings)
+}
+
+// RecordWith is Record with settings already loaded.
+func RecordWith(entry Entry, settings utils.Settings) error {
+	policy, err := PolicyFrom(settings.History)
+	if err != nil {
+		return err
+	}
+	redactor, err := NewRedactor(settings.History.RedactPatterns)
+	if err != nil {
+		return err
+	}
+
+	entry.Schema = SchemaVersion
+	if entry.ID == "" {
+		entry.ID = newID()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if !policy.CaptureOutput || !capturesOutput(entry.Command) {
+		entry.Stdout, entry.Stderr = "", ""
+		entry.StdoutCaptured, entry.StderrCaptured = false, false
+	}
+	redactor.redactCommand(&entry)
+	entry.Stdout, entry.Stderr = redactor.NormalizeOutput(entry.Stdout), redactor.NormalizeOutput(entry.Stderr)
+
+	offset, size, err := Append(Dir(), entry)
+	if err != nil {
+		return err
+	}
+	if policy.MaxBytes > 0 && size >= policy.MaxBytes {
+		return Compact(policy)
+	}
+	if policy.MaxEntries > 0 && offset/entryCheckInterval != size/entryCheckInterval {
+		count, err := Count(Dir())
+		if err != nil {
+			return err
+		}
+		if count > policy.MaxEntries+policy.entrySlack() {
+			return Compact(policy)
+		}
+	}
+	return nil
+}
+
+// Load returns all retained entries, oldest first, upgraded to the current
+// schema. Age and count limits are applied on read so they hold even before
+// the next compaction.
+func Load() ([]Entry, error) {
+	entries, err := ReadAll[Entry](Dir())
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		upgrade(&entries[i])
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	policy, err := LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	return policy.Apply(entries, time.Now()), nil
+}
+
+// redactCommand masks secrets in the entry's command. Args are dropped
+// when anything was masked, so replays never run with masked values.
+func (r *Redactor) redactCommand(e *Entry) {
+	if len(e.Args) == 0 {
+		e.Command = r.Redact(e.Command)
+		return
+	}
+	// Redact argument by argument: the joined line no longer shows where a
+	// quoted value with spaces ends
+	if command := r.RedactCommand(e.Args); command != strings.Join(e.Args, " ") {
+		e.Command, e.Args = command, nil
+	}
+}
+
+// migrateLegacyEntry turns a history.json record into a log line. It gets
+// the ID upgrade would derive before anything is masked, so IDs stay the
+// same, and is then redacted like a new recording.
+func migrateLegacyEntry(record json.RawMessage, redactor *Redactor) ([]byte, error) {
+	var e Entry
+	if err := json.Unmarshal(record, &e); err != nil {
+		return nil, err
+	}
+	upgrade(&e)
+	redactor.redactCommand(&e)
+	e.Stdout, e.Stderr = redactor.Redact(e.Stdout), redactor.Redact(e.Stderr)
+	return json.Marshal(e)
+}
+
+// upgrade brings an entry written by an older jfvm up to SchemaVersion.
+func upgrade(e *Entry) {
+	if e.Schema == 0 {
+		// Schema 1 (history.json) has the same fields; only the marker differs.
+		e.Schema = 1
+	}
+	if e.ID == "" {
+		// Entries recorded before IDs existed get one derived from their
+		// content, which stays the same on every load.
+		data, _ := json.Marshal(e)
+		sum := sha256.Sum256(data)
+		e.ID = hex.EncodeToString(sum[:idBytes])
+	}
+	// Entries from before the capture flags: output that is there was
+	// captured; empty output may not have been. Set after the ID so
+	// content-derived IDs don't change.
+	if e.Stdout != "" {
+		e.StdoutCaptured = true
+	}
+	if e.Stderr != "" {
+		e.StderrCaptured = true
+	}
+}
+
+// idBytes is the entry ID length in bytes (12 hex characters).
+const idBytes = 6
+
+func newID() string {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Find returns the entry whose ID is id or starts with it, like an
+// abbreviated git hash.
+func Find(entries []Entry, id string) (Entry, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return Entry{}, fmt.Errorf("empty entry ID")
+	}
+
+	var matches []Entry
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+		if strings.HasPrefix(e.ID, id) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Entry{}, fmt.Errorf("no history entry with ID %s", id)
+	case 1:
+		return matches[0], nil
+	}
+	return Entry{}, fmt.Errorf("entry ID %s is ambiguous (%d matches), use more characters", id, len(matches))
+}
+
+// NormalizeOutput truncates and redacts output the way Record does, so
+// fresh output can be compared with a recorded entry.
+func (r *Redactor) NormalizeOutput(s string) string {
+	return r.Redact(truncateOutput(s))
+}
+
+// truncateOutput keeps the tail of long output, which is where errors and
+// summaries usually are.
+func truncateOutput(s string) string {
+	if len(s) <= MaxOutputSize {
+		return s
+	}
+	return truncatedMarker + strings.TrimPrefix(s[len(s)-MaxOutputSize:], truncatedMarker)
+}
@@ -0,0 +1,285 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// useTempRoot points history and settings at a temporary jfvm root.
+func useTempRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	savedRoot, savedSettings := utils.JfvmRoot, utils.JfvmSettings
+	utils.JfvmRoot = root
+	utils.JfvmSettings = filepath.Join(root, utils.SettingsFile)
+	t.Cleanup(func() {
+		utils.JfvmRoot, utils.JfvmSettings = savedRoot, savedSettings
+	})
+	return root
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestRecordWritesStableJSONLines(t *testing.T) {
+	root := useTempRoot(t)
+	entry := Entry{
+		ID:             "abc123def456",
+		Version:        "2.74.0",
+		Timestamp:      time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Command:        "rt ping",
+		Duration:       42,
+		ExitCode:       1,
+		Stdout:         "OK\n",
+		Stderr:         "warn\n",
+		StdoutCaptured: true,
+		StderrCaptured: true,
+		Args:           []string{"rt", "ping"},
+		Cwd:            "/work",
+		Source:         "global config",
+		Spec:           "latest",
+		Hostname:       "ci-1",
+		Env:            []string{"JFROG_CLI_LOG_LEVEL"},
+		ServerID:       "prod",
+	}
+	if err := RecordWith(entry, utils.Settings{}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readLines(t, filepath.Join(root, FileName))
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1", len(lines))
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &raw); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := []string{
+		"args", "command", "cwd", "duration_ms", "env", "exit_code", "hostname", "id",
+		"schema", "server_id", "source", "spec", "stderr", "stderr_captured", "stdout",
+		"stdout_captured", "timestamp", "version",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v\nwant   %v", keys, want)
+	}
+	if raw["schema"] != float64(SchemaVersion) || SchemaVersion != 2 {
+		t.Errorf("schema = %v, want 2", raw["schema"])
+	}
+	if raw["timestamp"] != "2024-05-01T12:30:00Z" {
+		t.Errorf("timestamp = %v, want RFC 3339", raw["timestamp"])
+	}
+
+	entries, err := ReadAll[Entry](root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Schema = SchemaVersion
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0], entry) {
+		t.Errorf("read back %+v\nwant      %+v", entries, entry)
+	}
+}
+
+const legacyHistory = `[
+  {"version": "2.70.0", "timestamp": "2024-01-02T03:04:05Z", "command": "rt ping", "duration_ms": 10},
+  {"version": "2.72.0", "timestamp": "2024-01-03T03:04:05Z", "command": "--version", "stdout": "jf version 2.72.0\n"}
+]`
+
+func TestLoadMigratesLegacyHistory(t *testing.T) {
+	root := useTempRoot(t)
+	legacy := filepath.Join(root, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte(legacyHistory), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Recorded by a newer shim before the legacy file was migrated
+	current := `{"schema":2,"id":"000000000001","version":"2.74.0","timestamp":"2024-01-04T03:04:05Z","command":"rt ping"}`
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(current+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, e := range entries {
+		versions = append(versions, e.Version)
+	}
+	if want := []string{"2.70.0", "2.72.0", "2.74.0"}; !reflect.DeepEqual(versions, want) {
+		t.Fatalf("versions = %v, want %v", versions, want)
+	}
+	if entries[0].Schema != 1 || entries[2].Schema != 2 {
+		t.Errorf("schemas = %d, %d; want legacy 1 and current 2", entries[0].Schema, entries[2].Schema)
+	}
+	if !entries[1].StdoutCaptured || entries[0].StdoutCaptured {
+		t.Errorf("legacy capture flags should follow recorded output: %+v", entries[:2])
+	}
+
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("history.json still present (stat err: %v)", err)
+	}
+	if _, err := os.Stat(legacy + ".migrated"); !os.IsNotExist(err) {
+		t.Errorf("history.json.migrated kept (stat err: %v), want no unredacted copy", err)
+	}
+	if lines := readLines(t, filepath.Join(root, FileName)); len(lines) != 3 || lines[2] != current {
+		t.Errorf("history.jsonl = %q, want legacy entries before the existing one", lines)
+	}
+}
+
+func TestLoadSetsAsideCorruptLegacyHistory(t *testing.T) {
+	root := useTempRoot(t)
+	legacy := filepath.Join(root, LegacyFileName)
+	if err := os.WriteFile(legacy, []byte(`[{"version": "2.70.0",`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got %d entries from a corrupt file, want 0", len(entries))
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("history.json still present (stat err: %v)", err)
+	}
+	info, err := os.Stat(legacy + ".corrupt")
+	if err != nil {
+		t.Fatalf("history.json.corrupt missing: %v", err)
+	}
+	if info.Mode().Perm() != fileMode {
+		t.Errorf("history.json.corrupt mode = %v, want %v", info.Mode().Perm(), os.FileMode(fileMode))
+	}
+}
+
+func TestLoadGivesLegacyEntriesStableIDs(t *testing.T) {
+	root := useTempRoot(t)
+	if err := os.WriteFile(filepath.Join(root, LegacyFileName), []byte(legacyHistory), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// IDs are content hashes; users keep them in scripts and notes, so the
+	// derivation must not change between releases.
+	want := []string{"f7dd383af1de", "b0f3db67e54e"}
+	for i := range want {
+		if first[i].ID != second[i].ID {
+			t.Errorf("entry %d ID changed between loads: %s, %s", i, first[i].ID, second[i].ID)
+		}
+		if first[i].ID != want[i] {
+			t.Errorf("entry %d ID = %s, want %s", i, first[i].ID, want[i])
+		}
+	}
+	if first[0].ID == first[1].ID {
+		t.Errorf("different entries share ID %s", first[0].ID)
+	}
+}
+
+func TestMigrationRedactsLegacyEntries(t *testing.T) {
+	root := useTempRoot(t)
+	legacy := `[{"version": "2.70.0", "timestamp": "2024-01-02T03:04:05Z", "command": "rt ping --password hunter2",
+		"stdout": "token: JFROG_CLI_ACCESS_TOKEN=abc123"}]`
+	if err := os.WriteFile(filepath.Join(root, LegacyFileName), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The ID older versions derived from the unredacted entry
+	var original Entry
+	if err := json.Unmarshal([]byte(legacy[1:len(legacy)-1]), &original); err != nil {
+		t.Fatal(err)
+	}
+	upgrade(&original)
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "abc123"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("history.jsonl still holds %q:\n%s", secret, data)
+		}
+	}
+	if len(entries) != 1 || entries[0].Command != "rt ping --password ***" {
+		t.Fatalf("entries = %+v, want the redacted command", entries)
+	}
+	if entries[0].ID != original.ID {
+		t.Errorf("ID = %s, want %s as before redaction", entries[0].ID, original.ID)
+	}
+}
+
+func TestClearRemovesLegacyCopies(t *testing.T) {
+	root := useTempRoot(t)
+	legacy := filepath.Join(root, LegacyFileName)
+	paths := []string{filepath.Join(root, FileName), legacy + ".migrated", legacy + ".corrupt"}
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte("{}\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Clear(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s survived Clear (stat err: %v)", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestRecordEnforcesMaxEntriesOnAppend(t *testing.T) {
+	root := useTempRoot(t)
+	settings := utils.Settings{History: utils.HistorySettings{MaxEntries: 20}}
+	// About 10KB per entry, far below max_bytes in total
+	output := strings.Repeat("x", MaxOutputSize)
+	perInterval := entryCheckInterval / (2 * MaxOutputSize)
+
+	for i := 0; i < 100; i++ {
+		entry := Entry{
+			ID: fmt.Sprintf("entry%03d", i), Version: "2.74.0", Command: "rt ping",
+			Stdout: output, Stderr: output, StdoutCaptured: true, StderrCaptured: true,
+		}
+		if err := RecordWith(entry, settings); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := readLines(t, filepath.Join(root, FileName))
+	if limit := 20 + 2 + perInterval; len(lines) > limit {
+		t.Errorf("log holds %d entries, want at most %d (max_entries, slack and one count interval)", len(lines), limit)
+	}
+	var last Entry
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last.ID != "entry099" {
+		t.Errorf("last entry = %q (%v), want entry099", last.ID, err)
+	}
+}
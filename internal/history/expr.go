@@ -0,0 +1,361 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Expr is a compiled history query such as
+//
+//	version=2.74.* and duration>5s
+//	(exit_code!=0 or stderr~timeout) and not command~^c\b
+//
+// Comparisons take the form field op value and combine with and, or, not and
+// parentheses. Fields are version, command, cwd, source, spec, hostname,
+// server_id, stdout, stderr (strings), env (matches any recorded variable
+// name), exit_code, duration and time. Operators are = and != (strings
+// compare as globs where * matches anything), ~ and !~ (regular
+// expressions), and > >= < <=. Versions order by semver, durations accept 500ms/5s/2m or plain
+// milliseconds, and times accept the same forms as --since.
+type Expr struct {
+	source string
+	match  func(Entry) bool
+}
+
+// Match reports whether an entry satisfies the expression.
+func (x *Expr) Match(e Entry) bool {
+	return x.match(e)
+}
+
+func (x *Expr) String() string {
+	return x.source
+}
+
+// ParseExpr compiles a query expression.
+func ParseExpr(s string) (*Expr, error) {
+	p := &exprParser{src: s, now: time.Now()}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", s, err)
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("invalid query %q: unexpected %q", s, p.src[p.pos:])
+	}
+	return &Expr{source: s, match: match}, nil
+}
+
+type exprParser struct {
+	src string
+	pos int
+	now time.Time
+}
+
+// operators is ordered so two-character operators are tried first.
+var operators = []string{"!=", ">=", "<=", "!~", "=", ">", "<", "~"}
+
+func (p *exprParser) parseOr() (func(Entry) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e Entry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (func(Entry) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e Entry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (func(Entry) bool, error) {
+	if p.keyword("not") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e Entry) bool { return !inner(e) }, nil
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '(' {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != ')' {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (func(Entry) bool, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	field := strings.ToLower(p.src[start:p.pos])
+	if field == "" {
+		if p.pos >= len(p.src) {
+			return nil, fmt.Errorf("unexpected end of query")
+		}
+		return nil, fmt.Errorf("expected a field at %q", p.src[p.pos:])
+	}
+
+	p.skipSpace()
+	op := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(p.src[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("expected an operator after %q", field)
+	}
+	p.pos += len(op)
+
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return compare(field, op, value, p.now)
+}
+
+// value reads a quoted string or a bare word ending at whitespace or ')'.
+func (p *exprParser) value() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return "", fmt.Errorf("missing value")
+	}
+	if quote := p.src[p.pos]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(p.src[p.pos+1:], quote)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		v := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return v, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !unicode.IsSpace(rune(p.src[p.pos])) && p.src[p.pos] != ')' {
+		p.pos++
+	}
+	return p.src[start:p.pos], nil
+}
+
+// keyword consumes word if it is next, case-insensitively.
+func (p *exprParser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.src) || !strings.EqualFold(p.src[p.pos:end], word) {
+		return false
+	}
+	if end < len(p.src) && !unicode.IsSpace(rune(p.src[end])) && p.src[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// compare builds the matcher for one comparison, validating the value up
+// front so mistakes are reported before any entry is read.
+func compare(field, op, value string, now time.Time) (func(Entry) bool, error) {
+	switch field {
+	case "version":
+		return compareVersion(op, value)
+	case "command", "cmd":
+		return compareString(op, value, func(e Entry) string { return e.Command })
+	case "cwd", "dir":
+		return compareString(op, filepath.ToSlash(value), func(e Entry) string { return filepath.ToSlash(e.Cwd) })
+	case "source":
+		return compareString(op, filepath.ToSlash(value), func(e Entry) string { return filepath.ToSlash(e.Source) })
+	case "spec":
+		return compareString(op, value, func(e Entry) string { return e.Spec })
+	case "hostname", "host":
+		return compareString(op, value, func(e Entry) string { return e.Hostname })
+	case "server_id", "server":
+		return compareString(op, value, func(e Entry) string { return e.ServerID })
+	case "env":
+		return compareList(op, value, func(e Entry) []string { return e.Env })
+	case "stdout":
+		return compareString(op, value, func(e Entry) string { return e.Stdout })
+	case "stderr":
+		return compareString(op, value, func(e Entry) string { return e.Stderr })
+	case "exit_code", "exit":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code %q", value)
+		}
+		return compareOrdered(field, op, n, func(e Entry) int64 { return int64(e.ExitCode) })
+	case "duration":
+		d, err := parseDurationMillis(value)
+		if err != nil {
+			return nil, err
+		}
+		return compareOrdered(field, op, d, func(e Entry) int64 { return e.Duration })
+	case "time", "timestamp":
+		t, err := ParseTime(value, now)
+		if err != nil {
+			return nil, err
+		}
+		return compareOrdered(field, op, t.UnixNano(), func(e Entry) int64 { return e.Timestamp.UnixNano() })
+	}
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+func compareString(op, value string, get func(Entry) string) (func(Entry) bool, error) {
+	switch op {
+	case "=", "!=":
+		re, err := globRegexp(value)
+		if err != nil {
+			return nil, err
+		}
+		want := op == "="
+		return func(e Entry) bool { return re.MatchString(get(e)) == want }, nil
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		want := op == "~"
+		return func(e Entry) bool { return re.MatchString(get(e)) == want }, nil
+	}
+	return func(e Entry) bool { return ordered(op, strings.Compare(get(e), value)) }, nil
+}
+
+// compareList matches when any element matches; the negated operators match
+// when none does.
+func compareList(op, value string, get func(Entry) []string) (func(Entry) bool, error) {
+	positive := map[string]string{"=": "=", "!=": "=", "~": "~", "!~": "~"}[op]
+	if positive == "" {
+		return nil, fmt.Errorf("env only supports =, !=, ~ and !~")
+	}
+	element, err := compareString(positive, value, func(e Entry) string { return e.Command })
+	if err != nil {
+		return nil, err
+	}
+	want := op == positive
+	return func(e Entry) bool {
+		for _, item := range get(e) {
+			if element(Entry{Command: item}) {
+				return want
+			}
+		}
+		return !want
+	}, nil
+}
+
+func compareVersion(op, value string) (func(Entry) bool, error) {
+	if op == "=" || op == "!=" || op == "~" || op == "!~" {
+		return compareString(op, value, func(e Entry) string { return e.Version })
+	}
+	want, err := semver.NewVersion(value)
+	if err != nil {
+		// Aliases and other non-semver names order lexically
+		return compareString(op, value, func(e Entry) string { return e.Version })
+	}
+	return func(e Entry) bool {
+		got, err := semver.NewVersion(e.Version)
+		if err != nil {
+			return false
+		}
+		return ordered(op, got.Compare(want))
+	}, nil
+}
+
+func compareOrdered(field, op string, value int64, get func(Entry) int64) (func(Entry) bool, error) {
+	if op == "~" || op == "!~" {
+		return nil, fmt.Errorf("%s does not support %s", field, op)
+	}
+	return func(e Entry) bool {
+		got := get(e)
+		switch {
+		case got < value:
+			return ordered(op, -1)
+		case got > value:
+			return ordered(op, 1)
+		}
+		return ordered(op, 0)
+	}, nil
+}
+
+// ordered reports whether a comparison result satisfies op.
+func ordered(op string, cmp int) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// globRegexp compiles a whole-string glob where * matches any run of
+// characters, including '/' and spaces.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	parts := strings.Split(glob, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// parseDurationMillis accepts a duration such as 5s or a plain number of
+// milliseconds, matching how durations are stored.
+func parseDurationMillis(value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d.Milliseconds(), nil
+}
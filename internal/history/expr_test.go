@@ -0,0 +1,129 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExprMatches(t *testing.T) {
+	entry := Entry{
+		Version:   "2.74.1",
+		Command:   "rt upload build/*.zip repo",
+		Cwd:       "/work/app",
+		Hostname:  "ci-1",
+		Env:       []string{"JFROG_CLI_LOG_LEVEL", "JFROG_CLI_BUILD_NAME"},
+		Stderr:    "connection timeout\n",
+		ExitCode:  1,
+		Duration:  6000,
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local),
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		// Operators
+		{"version=2.74.1", true},
+		{"version=2.74.*", true},
+		{"version=2.7*", true},
+		{"version!=2.74.*", false},
+		{"command~^rt\\s+up", true},
+		{"command!~^rt", false},
+		{"version>2.74.0", true},
+		{"version>=2.74.1", true},
+		{"version<2.9.0", false},
+		{"version<=2.74.1", true},
+		{"exit_code=1", true},
+		{"exit_code!=0", true},
+		{"exit>0", true},
+		{"exit_code<1", false},
+		{"duration>5s", true},
+		{"duration>=6000", true},
+		{"duration<6s", false},
+		{"duration<=6s", true},
+		{"time>2024-05-01", true},
+		{"time<2024-05-01T12:00:00", false},
+		{"time<=2024-05-01T12:00:00", true},
+		{"cwd=/work/*", true},
+		{"host=ci-1", true},
+		{"env=JFROG_CLI_BUILD_*", true},
+		{"env!=JFROG_CLI_BUILD_*", false},
+		{"env~LOG", true},
+		{"env!~^HOME$", true},
+		{"stderr~timeout", true},
+
+		// Precedence: not binds tightest, then and, then or
+		{"exit_code=0 or version=2.74.1 and stderr~timeout", true},
+		{"version=2.74.1 or exit_code=0 and stderr~nothing", true},
+		{"(version=2.74.1 or exit_code=0) and stderr~nothing", false},
+		{"not exit_code=0 and version=2.74.*", true},
+		{"not (exit_code=1 and version=2.74.*)", false},
+		{"not not exit_code=1", true},
+		{"NOT exit_code=0 AND (host=ci-1 OR host=ci-2)", true},
+
+		// Quoting
+		{`command="rt upload build/*.zip repo"`, true},
+		{`command='rt upload build/*.zip repo'`, true},
+		{`command="rt upload"`, false},
+		{`stderr~"connection timeout"`, true},
+		{`command~'\*\.zip'`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			x, err := ParseExpr(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := x.Match(entry); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExprVersionOrdersBySemver(t *testing.T) {
+	x, err := ParseExpr("version>=2.9.0 and version<2.10.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for version, want := range map[string]bool{"2.9.3": true, "2.10.0": false, "2.8.9": false, "latest": false} {
+		if got := x.Match(Entry{Version: version}); got != want {
+			t.Errorf("%s: Match = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"", "unexpected end of query"},
+		{"version", `expected an operator after "version"`},
+		{"version=", "missing value"},
+		{"colour=red", `unknown field "colour"`},
+		{"=2.74.0", "expected a field"},
+		{"(version=2.74.0", "missing )"},
+		{"version=2.74.0)", `unexpected ")"`},
+		{"version=2.74.0 and", "unexpected end of query"},
+		{`command="rt ping`, "unterminated string"},
+		{"command~(", "invalid regular expression"},
+		{"exit_code=one", `invalid exit code "one"`},
+		{"duration>soon", `invalid duration "soon"`},
+		{"time>yesterday", `invalid time "yesterday"`},
+		{"exit_code~1", "exit_code does not support ~"},
+		{"env>JFROG", "env only supports"},
+		{"version=2.74.0 version=2.75.0", "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseExpr(tt.query)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
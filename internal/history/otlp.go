@@ -0,0 +1,209 @@
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP/JSON span kinds and status codes, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+
+	// OTLPTracesPath is appended to an endpoint that does not already name
+	// the traces signal.
+	OTLPTracesPath = "/v1/traces"
+
+	otlpScopeName = "github.com/bhanurp/jfvm/history"
+)
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue; OTLP/JSON encodes 64-bit integers as strings.
+type otlpValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	ArrayValue  *otlpValues `json:"arrayValue,omitempty"`
+}
+
+type otlpValues struct {
+	Values []otlpValue `json:"values"`
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) otlpAttribute {
+	s := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+// OTLPSpans encodes entries as an OTLP/JSON ExportTraceServiceRequest, one
+// span per jf invocation, grouped into one resource per host. Span IDs are
+// derived from the entry, so exporting the same history twice yields the
+// same spans and collectors can deduplicate them.
+func OTLPSpans(entries []Entry, serviceName string) ([]byte, error) {
+	byHost := make(map[string][]otlpSpan)
+	for _, e := range entries {
+		span, err := otlpSpanFor(e)
+		if err != nil {
+			return nil, err
+		}
+		byHost[e.Hostname] = append(byHost[e.Hostname], span)
+	}
+
+	hosts := make([]string, 0, len(byHost))
+	for host := range byHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{}}
+	for _, host := range hosts {
+		attrs := []otlpAttribute{stringAttr("service.name", serviceName)}
+		if host != "" {
+			attrs = append(attrs, stringAttr("host.name", host))
+		}
+		req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+			Resource: otlpResource{Attributes: attrs},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: byHost[host],
+			}},
+		})
+	}
+
+	return json.Marshal(req)
+}
+
+func otlpSpanFor(e Entry) (otlpSpan, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return otlpSpan{}, err
+	}
+	sum := sha256.Sum256(data)
+
+	end := e.Timestamp.Add(time.Duration(e.Duration) * time.Millisecond)
+	attrs := []otlpAttribute{
+		stringAttr("jf.version", e.Version),
+		stringAttr("jf.command", e.Command),
+		intAttr("jf.exit_code", int64(e.ExitCode)),
+	}
+	optional := []struct{ key, value string }{
+		{"jf.version_spec", e.Spec},
+		{"jf.version_source", e.Source},
+		{"jf.server_id", e.ServerID},
+		{"process.working_directory", e.Cwd},
+	}
+	for _, attr := range optional {
+		if attr.value != "" {
+			attrs = append(attrs, stringAttr(attr.key, attr.value))
+		}
+	}
+	if len(e.Env) > 0 {
+		values := make([]otlpValue, len(e.Env))
+		for i := range e.Env {
+			values[i] = otlpValue{StringValue: &e.Env[i]}
+		}
+		attrs = append(attrs, otlpAttribute{Key: "jf.env", Value: otlpValue{ArrayValue: &otlpValues{values}}})
+	}
+
+	status := otlpStatus{Code: otlpStatusOK}
+	if e.ExitCode != 0 {
+		status = otlpStatus{Code: otlpStatusError, Message: fmt.Sprintf("exit code %d", e.ExitCode)}
+	}
+
+	return otlpSpan{
+		TraceID:           hex.EncodeToString(sum[:16]),
+		SpanID:            hex.EncodeToString(sum[16:24]),
+		Name:              strings.TrimSpace("jf " + CommandName(e.Command)),
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(e.Timestamp.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Attributes:        attrs,
+		Status:            status,
+	}, nil
+}
+
+// SendOTLP posts an OTLP/JSON payload to an OTLP HTTP collector.
+func SendOTLP(endpoint string, headers map[string]string, payload []byte) error {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, OTLPTracesPath) {
+		url += OTLPTracesPath
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector at %s returned %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
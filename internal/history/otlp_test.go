@@ -0,0 +1,171 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func otlpEntries() []Entry {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []Entry{
+		{Version: "2.74.0", Command: "rt ping", Timestamp: start, Duration: 250, Hostname: "ci-2"},
+		{Version: "2.74.0", Command: "rt upload a.txt repo/", Timestamp: start.Add(time.Minute), Duration: 1000,
+			Hostname: "ci-1", Spec: "latest", Env: []string{"JFROG_CLI_LOG_LEVEL"}},
+		{Version: "2.75.0", Command: "fail now", Timestamp: start.Add(2 * time.Minute), ExitCode: 2, Hostname: "ci-1"},
+	}
+}
+
+func decodeOTLP(t *testing.T, data []byte) otlpRequest {
+	t.Helper()
+	var req otlpRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("invalid OTLP/JSON: %v\n%s", err, data)
+	}
+	return req
+}
+
+func TestOTLPSpans(t *testing.T) {
+	payload, err := OTLPSpans(otlpEntries(), "jfvm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := decodeOTLP(t, payload)
+
+	// One resource per host, sorted by host name
+	if len(req.ResourceSpans) != 2 {
+		t.Fatalf("got %d resources, want one per host", len(req.ResourceSpans))
+	}
+	hosts := []string{"ci-1", "ci-2"}
+	for i, rs := range req.ResourceSpans {
+		attrs := map[string]string{}
+		for _, a := range rs.Resource.Attributes {
+			attrs[a.Key] = *a.Value.StringValue
+		}
+		if attrs["service.name"] != "jfvm-test" || attrs["host.name"] != hosts[i] {
+			t.Errorf("resource %d attributes = %v, want jfvm-test on %s", i, attrs, hosts[i])
+		}
+		if rs.ScopeSpans[0].Scope.Name != otlpScopeName {
+			t.Errorf("scope = %q", rs.ScopeSpans[0].Scope.Name)
+		}
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("ci-1 has %d spans, want 2", len(spans))
+	}
+	upload, failed := spans[0], spans[1]
+	if upload.Name != "jf rt upload" || upload.Kind != otlpSpanKindInternal {
+		t.Errorf("span name/kind = %q/%d, want jf rt upload/internal", upload.Name, upload.Kind)
+	}
+	if len(upload.TraceID) != 32 || len(upload.SpanID) != 16 {
+		t.Errorf("trace/span IDs %q/%q are not 16/8 bytes of hex", upload.TraceID, upload.SpanID)
+	}
+	if upload.StartTimeUnixNano != "1714564860000000000" || upload.EndTimeUnixNano != "1714564861000000000" {
+		t.Errorf("span times = %s..%s, want the entry's start plus its duration", upload.StartTimeUnixNano, upload.EndTimeUnixNano)
+	}
+	attrs := map[string]otlpValue{}
+	for _, a := range upload.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if v := attrs["jf.exit_code"].IntValue; v == nil || *v != "0" {
+		t.Errorf("jf.exit_code = %v, want the string \"0\"", v)
+	}
+	if v := attrs["jf.version_spec"].StringValue; v == nil || *v != "latest" {
+		t.Errorf("jf.version_spec = %v, want latest", v)
+	}
+	if _, ok := attrs["jf.server_id"]; ok {
+		t.Error("empty jf.server_id exported")
+	}
+	if env := attrs["jf.env"].ArrayValue; env == nil || len(env.Values) != 1 || *env.Values[0].StringValue != "JFROG_CLI_LOG_LEVEL" {
+		t.Errorf("jf.env = %+v", env)
+	}
+	if upload.Status.Code != otlpStatusOK {
+		t.Errorf("successful span status = %+v", upload.Status)
+	}
+	if failed.Status.Code != otlpStatusError || failed.Status.Message != "exit code 2" {
+		t.Errorf("failed span status = %+v, want an error with the exit code", failed.Status)
+	}
+
+	again, err := OTLPSpans(otlpEntries(), "jfvm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(payload, again) {
+		t.Error("exporting the same entries twice gave different spans")
+	}
+}
+
+func TestOTLPSpansWithoutEntries(t *testing.T) {
+	payload, err := OTLPSpans(nil, "jfvm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != `{"resourceSpans":[]}` {
+		t.Errorf("payload = %s, want an empty request", payload)
+	}
+}
+
+func TestSendOTLP(t *testing.T) {
+	tests := []struct {
+		name, endpoint string
+	}{
+		{"base endpoint", ""},
+		{"trailing slash", "/"},
+		{"traces endpoint", OTLPTracesPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				method, path, contentType, apiKey string
+				body                              []byte
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got.method, got.path = r.Method, r.URL.Path
+				got.contentType, got.apiKey = r.Header.Get("Content-Type"), r.Header.Get("X-Api-Key")
+				got.body, _ = io.ReadAll(r.Body)
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			payload, err := OTLPSpans(otlpEntries(), "jfvm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := SendOTLP(server.URL+tt.endpoint, map[string]string{"X-Api-Key": "s3cr=t"}, payload); err != nil {
+				t.Fatal(err)
+			}
+			if got.method != http.MethodPost || got.path != OTLPTracesPath {
+				t.Errorf("request = %s %s, want POST %s", got.method, got.path, OTLPTracesPath)
+			}
+			if got.contentType != "application/json" || got.apiKey != "s3cr=t" {
+				t.Errorf("headers = %q, %q; want application/json and the configured key", got.contentType, got.apiKey)
+			}
+			if !bytes.Equal(got.body, payload) {
+				t.Errorf("collector received %s, want the payload", got.body)
+			}
+		})
+	}
+}
+
+func TestSendOTLPReportsCollectorErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "malformed span", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := SendOTLP(server.URL, nil, []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request: malformed span") {
+		t.Errorf("error = %v, want the collector's status and message", err)
+	}
+
+	server.Close()
+	if err := SendOTLP(server.URL, nil, []byte("{}")); err == nil || !strings.Contains(err.Error(), "failed to send spans") {
+		t.Errorf("unreachable collector: error = %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// Policy controls what is recorded and how much history is retained.
+// Zero limits mean unlimited.
+type Policy struct {
+	MaxEntries    int
+	MaxAge        time.Duration
+	MaxBytes      int64
+	CaptureOutput bool
+}
+
+// DefaultPolicy applies when settings.json does not override a value.
+var DefaultPolicy = Policy{
+	MaxEntries:    10000,
+	MaxBytes:      20 << 20,
+	CaptureOutput: true,
+}
+
+// LoadPolicy reads the retention settings from settings.json.
+func LoadPolicy() (Policy, error) {
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		return DefaultPolicy, err
+	}
+	return PolicyFrom(settings.History)
+}
+
+// PolicyFrom builds the policy from already loaded history settings.
+func PolicyFrom(h utils.HistorySettings) (Policy, error) {
+	p := DefaultPolicy
+	if h.MaxEntries > 0 {
+		p.MaxEntries = h.MaxEntries
+	}
+	if h.MaxBytes > 0 {
+		p.MaxBytes = h.MaxBytes
+	}
+	if h.MaxAge != "" {
+		age, err := ParseDuration(h.MaxAge)
+		if err != nil {
+			return p, fmt.Errorf("invalid history.max_age: %w", err)
+		}
+		p.MaxAge = age
+	}
+	if h.CaptureOutput != nil {
+		p.CaptureOutput = *h.CaptureOutput
+	}
+	return p, nil
+}
+
+// Apply drops entries beyond the age and count limits. Entries must be
+// oldest first.
+func (p Policy) Apply(entries []Entry, now time.Time) []Entry {
+	if p.MaxAge > 0 {
+		cutoff := now.Add(-p.MaxAge)
+		i := 0
+		for i < len(entries) && entries[i].Timestamp.Before(cutoff) {
+			i++
+		}
+		entries = entries[i:]
+	}
+	if p.MaxEntries > 0 && len(entries) > p.MaxEntries {
+		entries = entries[len(entries)-p.MaxEntries:]
+	}
+	return entries
+}
+
+// entryCheckInterval is how far the log grows between entry counts on
+// append. Counting reads the whole log, so it is not done on every append.
+const entryCheckInterval = 64 << 10
+
+// entrySlack is how far the log may run past MaxEntries before an append
+// compacts it, so that appends past the limit don't each rewrite the log.
+func (p Policy) entrySlack() int {
+	return p.MaxEntries / 10
+}
+
+// compactTarget is the size a compaction trims the log down to, leaving
+// headroom so the next compaction is not triggered by the next append.
+func (p Policy) compactTarget() int64 {
+	return p.MaxBytes * 3 / 4
+}
+
+// Compact rewrites the log so it satisfies the policy.
+func Compact(p Policy) error {
+	return Rewrite(Dir(), func(entries []Entry) []Entry {
+		entries = p.Apply(entries, time.Now())
+		if p.MaxBytes <= 0 {
+			return entries
+		}
+
+		// Keep the newest entries that fit in the byte budget
+		var size int64
+		for i := len(entries) - 1; i >= 0; i-- {
+			line, err := json.Marshal(entries[i])
+			if err != nil {
+				continue
+			}
+			size += int64(len(line)) + 1
+			if size > p.compactTarget() {
+				return entries[i+1:]
+			}
+		}
+		return entries
+	})
+}
+
+// Prune removes entries for which match returns true and returns them.
+// Entries already past the retention limits are dropped without being
+// reported. With dryRun set the log is left untouched.
+func Prune(match func(Entry) bool, dryRun bool) ([]Entry, error) {
+	policy, err := LoadPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Entry
+	keep := func(entries []Entry) []Entry {
+		entries = policy.Apply(entries, time.Now())
+		kept := []Entry{}
+		for _, e := range entries {
+			if match(e) {
+				removed = append(removed, e)
+			} else {
+				kept = append(kept, e)
+			}
+		}
+		return kept
+	}
+
+	if dryRun {
+		entries, err := Load()
+		if err != nil {
+			return nil, err
+		}
+		keep(entries)
+		return removed, nil
+	}
+
+	err = Rewrite(Dir(), keep)
+	return removed, err
+}
+
+// ParseDuration extends time.ParseDuration with day ("7d") and week ("2w")
+// units.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,127 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filter selects entries. Zero-value fields match everything.
+type Filter struct {
+	Version string
+	// Command matches a case-insensitive substring of the command line.
+	Command string
+	// CommandRegex matches the command line as a regular expression.
+	CommandRegex *regexp.Regexp
+	FailuresOnly bool
+	// ExitCode, when set, matches one exit code exactly.
+	ExitCode *int
+	// Since and Until bound the timestamp, inclusive.
+	Since time.Time
+	Until time.Time
+	// MinDuration skips faster invocations.
+	MinDuration time.Duration
+	// Cwd matches entries run in a directory or below it.
+	Cwd string
+	// Source matches a substring of what selected the version.
+	Source   string
+	Hostname string
+	ServerID string
+	// Where is an optional query expression, see ParseExpr.
+	Where *Expr
+}
+
+// Match reports whether an entry satisfies the filter.
+func (f Filter) Match(e Entry) bool {
+	if f.Version != "" && e.Version != f.Version {
+		return false
+	}
+	if f.Command != "" && !strings.Contains(strings.ToLower(e.Command), strings.ToLower(f.Command)) {
+		return false
+	}
+	if f.CommandRegex != nil && !f.CommandRegex.MatchString(e.Command) {
+		return false
+	}
+	if f.FailuresOnly && e.ExitCode == 0 {
+		return false
+	}
+	if f.ExitCode != nil && e.ExitCode != *f.ExitCode {
+		return false
+	}
+	if !f.Since.IsZero() && e.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Timestamp.After(f.Until) {
+		return false
+	}
+	if f.MinDuration > 0 && e.Duration < f.MinDuration.Milliseconds() {
+		return false
+	}
+	if f.Cwd != "" && !withinDir(e.Cwd, f.Cwd) {
+		return false
+	}
+	if f.Source != "" && !strings.Contains(e.Source, f.Source) {
+		return false
+	}
+	if f.Hostname != "" && e.Hostname != f.Hostname {
+		return false
+	}
+	if f.ServerID != "" && e.ServerID != f.ServerID {
+		return false
+	}
+	if f.Where != nil && !f.Where.Match(e) {
+		return false
+	}
+	return true
+}
+
+// Query returns the entries matching the filter, preserving order.
+func Query(entries []Entry, f Filter) []Entry {
+	matched := []Entry{}
+	for _, e := range entries {
+		if f.Match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// timeLayouts are the absolute forms accepted by ParseTime, in local time
+// unless they carry a zone.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime accepts an absolute time (RFC 3339 or a date with an optional
+// time of day) or a duration such as 2h or 7d meaning that long before now.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a date like 2006-01-02, RFC 3339 or a duration like 2h or 7d", s)
+}
+
+// withinDir reports whether path is dir or below it. Entries recorded
+// before the working directory was captured never match.
+func withinDir(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
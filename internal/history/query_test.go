@@ -0,0 +1,99 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2h", now.Add(-2 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{" 1.5d ", now.Add(-36 * time.Hour)},
+		{"2024-05-01T08:00:00Z", time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{"2024-05-01T08:00:00+02:00", time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)},
+		{"2024-05-01T08:00:00", time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)},
+		{"2024-05-01 08:00:30", time.Date(2024, 5, 1, 8, 0, 30, 0, time.Local)},
+		{"2024-05-01 08:00", time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "yesterday", "2024-13-01", "05/01/2024", "7x"} {
+		if _, err := ParseTime(in, now); err == nil {
+			t.Errorf("ParseTime(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Version:   "2.74.0",
+		Command:   "rt Ping --server-id prod",
+		ExitCode:  2,
+		Duration:  1500,
+		Timestamp: base,
+		Cwd:       "/work/app",
+		Source:    "/work/.jfrog-version",
+		Hostname:  "ci-1",
+		ServerID:  "prod",
+	}
+	exit := func(code int) *int { return &code }
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"version", Filter{Version: "2.74.0"}, true},
+		{"other version", Filter{Version: "2.74"}, false},
+		{"command substring ignores case", Filter{Command: "rt ping"}, true},
+		{"failures only", Filter{FailuresOnly: true}, true},
+		{"exit code", Filter{ExitCode: exit(2)}, true},
+		{"other exit code", Filter{ExitCode: exit(0)}, false},
+		{"since is inclusive", Filter{Since: base}, true},
+		{"since later", Filter{Since: base.Add(time.Second)}, false},
+		{"until is inclusive", Filter{Until: base}, true},
+		{"until earlier", Filter{Until: base.Add(-time.Second)}, false},
+		{"within since and until", Filter{Since: base.Add(-time.Hour), Until: base.Add(time.Hour)}, true},
+		{"min duration reached", Filter{MinDuration: 1500 * time.Millisecond}, true},
+		{"min duration missed", Filter{MinDuration: 2 * time.Second}, false},
+		{"cwd parent", Filter{Cwd: "/work"}, true},
+		{"cwd sibling prefix", Filter{Cwd: "/work/ap"}, false},
+		{"source substring", Filter{Source: ".jfrog-version"}, true},
+		{"hostname", Filter{Hostname: "ci-2"}, false},
+		{"server id", Filter{ServerID: "prod"}, true},
+		{"all must match", Filter{Version: "2.74.0", ExitCode: exit(0)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(entry); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (Filter{FailuresOnly: true}).Match(Entry{}) {
+		t.Error("failures only matched a successful entry")
+	}
+	if (Filter{Cwd: "/work"}).Match(Entry{}) {
+		t.Error("cwd matched an entry recorded without one")
+	}
+}
@@ -0,0 +1,294 @@
+// Package history stores jf invocation history as an append-only JSON Lines
+// log that the shim and the jfvm CLI can write to concurrently.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bhanurp/jfvm/internal/filelock"
+)
+
+const (
+	// FileName is the JSON Lines log.
+	FileName = "history.jsonl"
+	// LegacyFileName is the JSON array written by older jfvm versions.
+	LegacyFileName = "history.json"
+
+	lockFileName = "history.lock"
+
+	// maxLineSize bounds a single entry when reading the log back.
+	maxLineSize = 4 << 20
+
+	// fileMode keeps history private: commands and output can hold secrets
+	// that slipped past redaction.
+	fileMode = 0600
+)
+
+// Append writes one record as a single JSON line and returns the offset it
+// was written at and the resulting log size. Writers hold an exclusive
+// lock, so concurrent shims never interleave or lose entries.
+func Append(dir string, record any) (offset, size int64, err error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return 0, 0, err
+	}
+	line = append(line, '\n')
+
+	lock, err := filelock.Exclusive(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer lock.Unlock()
+
+	if err := migrateLegacy(dir); err != nil {
+		return 0, 0, err
+	}
+
+	path := filepath.Join(dir, FileName)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fileMode)
+	if err != nil {
+		return 0, 0, err
+	}
+	if info, err := f.Stat(); err == nil {
+		offset = info.Size()
+		if info.Mode().Perm() != fileMode {
+			// Tighten logs created by older versions
+			_ = f.Chmod(fileMode)
+		}
+	}
+	_, werr := f.Write(line)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return 0, 0, werr
+	}
+
+	// The exclusive lock means nobody else appended in between
+	return offset, offset + int64(len(line)), nil
+}
+
+// Count returns the number of lines in the log without decoding them.
+func Count(dir string) (int, error) {
+	lock, err := filelock.Shared(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		count += bytes.Count(buf[:n], []byte{'\n'})
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ReadAll decodes every record, oldest first. Lines that fail to decode are
+// skipped rather than discarding the whole history.
+func ReadAll[T any](dir string) ([]T, error) {
+	if _, err := os.Stat(filepath.Join(dir, LegacyFileName)); err == nil {
+		lock, err := filelock.Exclusive(filepath.Join(dir, lockFileName))
+		if err != nil {
+			return nil, err
+		}
+		err = migrateLegacy(dir)
+		lock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lock, err := filelock.Shared(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return readLog[T](dir)
+}
+
+// Rewrite replaces the log with fn applied to all records, under the
+// exclusive lock. It is used for pruning and retention.
+func Rewrite[T any](dir string, fn func([]T) []T) error {
+	lock, err := filelock.Exclusive(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if err := migrateLegacy(dir); err != nil {
+		return err
+	}
+
+	records, err := readLog[T](dir)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, record := range fn(records) {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	return replaceLog(dir, buf.Bytes())
+}
+
+func readLog[T any](dir string) ([]T, error) {
+	path := filepath.Join(dir, FileName)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []T
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record T
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return records, nil
+}
+
+// replaceLog atomically swaps in new log content. The caller must hold the
+// exclusive lock.
+func replaceLog(dir string, data []byte) error {
+	active := filepath.Join(dir, FileName)
+	tmp := active + ".tmp"
+	if err := os.WriteFile(tmp, data, fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, active); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Clear removes the log, any legacy history file and the copies older
+// versions set aside, which may hold unredacted secrets.
+func Clear(dir string) error {
+	lock, err := filelock.Exclusive(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	legacy := filepath.Join(dir, LegacyFileName)
+	for _, path := range append(logFiles(dir), legacy+".migrated", legacy+".corrupt") {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists reports whether any history has been recorded.
+func Exists(dir string) bool {
+	for _, path := range logFiles(dir) {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func logFiles(dir string) []string {
+	return []string{filepath.Join(dir, FileName), filepath.Join(dir, LegacyFileName)}
+}
+
+// migrateLegacy converts history.json (a JSON array) into JSON Lines placed
+// before any existing entries, then removes it: older versions stored
+// commands and output unredacted, so no copy is kept. An unreadable legacy
+// file is set aside as history.json.corrupt instead of being silently
+// dropped. The caller must hold the exclusive lock.
+func migrateLegacy(dir string) error {
+	legacy := filepath.Join(dir, LegacyFileName)
+	data, err := os.ReadFile(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(data, &records); err != nil {
+		return renamePrivate(legacy, legacy+".corrupt")
+	}
+	redactor, err := LoadRedactor()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, record := range records {
+		line, err := migrateLegacyEntry(record, redactor)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	existing, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	buf.Write(existing)
+
+	if err := replaceLog(dir, buf.Bytes()); err != nil {
+		return err
+	}
+	return os.Remove(legacy)
+}
+
+// renamePrivate sets aside a legacy file, which was written world-readable.
+func renamePrivate(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	return os.Chmod(to, fileMode)
+}
@@ -0,0 +1,145 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// appenderEnv makes the test binary act as one writer process for
+// TestAppendConcurrentProcesses instead of running the tests.
+const appenderEnv = "JFVM_TEST_HISTORY_APPENDER"
+
+func TestMain(m *testing.M) {
+	if spec := os.Getenv(appenderEnv); spec != "" {
+		if err := runAppender(spec); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stressRecord is padded past PIPE_BUF so unlocked appends could interleave.
+type stressRecord struct {
+	Writer  int    `json:"writer"`
+	Seq     int    `json:"seq"`
+	Padding string `json:"padding"`
+}
+
+var stressPadding = strings.Repeat("x", 8<<10)
+
+// runAppender handles "dir:writer:count" and appends count records from
+// count goroutines.
+func runAppender(spec string) error {
+	parts := strings.Split(spec, ":")
+	dir := strings.Join(parts[:len(parts)-2], ":")
+	writer, _ := strconv.Atoi(parts[len(parts)-2])
+	count, _ := strconv.Atoi(parts[len(parts)-1])
+	return appendConcurrently(dir, writer, count)
+}
+
+func appendConcurrently(dir string, writer, count int) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, count)
+	for seq := 0; seq < count; seq++ {
+		wg.Add(1)
+		go func(seq int) {
+			defer wg.Done()
+			if _, _, err := Append(dir, stressRecord{Writer: writer, Seq: seq, Padding: stressPadding}); err != nil {
+				errs <- err
+			}
+		}(seq)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func TestAppendConcurrentProcesses(t *testing.T) {
+	const processes, perProcess = 8, 25
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for writer := 0; writer < processes; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^$")
+			cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s:%d:%d", appenderEnv, dir, writer, perProcess))
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("writer %d failed: %v\n%s", writer, err, out)
+			}
+		}(writer)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	assertStressLog(t, dir, processes, perProcess)
+}
+
+func TestAppendConcurrentGoroutines(t *testing.T) {
+	const writers, perWriter = 4, 50
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			if err := appendConcurrently(dir, writer, perWriter); err != nil {
+				t.Errorf("writer %d: %v", writer, err)
+			}
+		}(writer)
+	}
+	wg.Wait()
+
+	assertStressLog(t, dir, writers, perWriter)
+}
+
+// assertStressLog checks the log has exactly one intact line per appended
+// record.
+func assertStressLog(t *testing.T, dir string, writers, perWriter int) {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	seen := make(map[[2]int]bool)
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		lines++
+		var record stressRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d does not parse: %v", lines, err)
+		}
+		if record.Padding != stressPadding {
+			t.Fatalf("line %d has corrupted padding", lines)
+		}
+		key := [2]int{record.Writer, record.Seq}
+		if seen[key] {
+			t.Errorf("record %v written twice", key)
+		}
+		seen[key] = true
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := writers * perWriter; lines != want || len(seen) != want {
+		t.Errorf("got %d lines (%d unique records), want %d", lines, len(seen), want)
+	}
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// RemoteCacheTTL is how long a release listing is reused before the
+// release repository is queried again.
+const RemoteCacheTTL = time.Hour
+
+const remoteCacheFile = "remote-versions.json"
+
+type remoteCache struct {
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Versions  []string  `json:"versions"`
+}
+
+type storageListing struct {
+	Children []struct {
+		URI    string `json:"uri"`
+		Folder bool   `json:"folder"`
+	} `json:"children"`
+}
+
+var hrefPattern = regexp.MustCompile(`href="([^"/?]+)/"`)
+
+// ListRemoteVersions returns the releases available from src, newest first.
+// The listing is cached under ~/.jfvm/cache unless refresh is set.
+func ListRemoteVersions(src Source, refresh bool) ([]string, error) {
+	listURL := src.listingURL()
+
+	if !refresh {
+		if cached, ok := readRemoteCache(listURL); ok {
+			return cached, nil
+		}
+	}
+
+	names, err := src.fetchStorageListing()
+	if err != nil {
+		// Not every mirror exposes the storage API; fall back to the
+		// browsable directory index.
+		names, err = src.fetchIndexListing()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases at %s: %w", listURL, err)
+		}
+	}
+
+	versions := SortVersions(names)
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no releases found at %s", listURL)
+	}
+
+	writeRemoteCache(listURL, versions)
+	return versions, nil
+}
+
+// SortVersions drops names that are not semantic versions and orders the
+// rest newest first.
+func SortVersions(names []string) []string {
+	type parsed struct {
+		name    string
+		version *semver.Version
+	}
+
+	var valid []parsed
+	for _, name := range names {
+		v, err := semver.NewVersion(name)
+		if err != nil {
+			continue
+		}
+		valid = append(valid, parsed{name, v})
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].version.GreaterThan(valid[j].version)
+	})
+
+	versions := make([]string, len(valid))
+	for i, p := range valid {
+		versions[i] = p.name
+	}
+	return versions
+}
+
+// listingDir is the part of the layout before the version segment, e.g.
+// "v2-jf" for the default layout.
+func (s Source) listingDir() string {
+	dir := s.Layout
+	if i := strings.Index(dir, "{version}"); i >= 0 {
+		dir = dir[:i]
+	}
+	return strings.Trim(dir, "/")
+}
+
+func (s Source) listingURL() string {
+	return s.BaseURL + "/" + s.listingDir() + "/"
+}
+
+// fetchStorageListing uses the Artifactory storage API. The base URL is
+// expected to be "<artifactory>/<repo>".
+func (s Source) fetchStorageListing() ([]string, error) {
+	u, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	root, repo := path.Split(strings.TrimRight(u.Path, "/"))
+	if repo == "" {
+		return nil, fmt.Errorf("cannot derive repository from %s", s.BaseURL)
+	}
+	u.Path = strings.TrimRight(root, "/") + "/api/storage/" + repo + "/" + s.listingDir()
+
+	resp, err := s.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("storage API returned %s", resp.Status)
+	}
+
+	var listing storageListing
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		return nil, fmt.Errorf("invalid storage API response: %w", err)
+	}
+
+	var names []string
+	for _, child := range listing.Children {
+		if child.Folder {
+			names = append(names, strings.Trim(child.URI, "/"))
+		}
+	}
+	return names, nil
+}
+
+func (s Source) fetchIndexListing() ([]string, error) {
+	resp, err := s.Get(s.listingURL())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("directory listing returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range hrefPattern.FindAllStringSubmatch(string(body), -1) {
+		names = append(names, m[1])
+	}
+	return names, nil
+}
+
+func readRemoteCache(source string) ([]string, bool) {
+	data, err := os.ReadFile(filepath.Join(utils.JfvmCache, remoteCacheFile))
+	if err != nil {
+		return nil, false
+	}
+
+	var cache remoteCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+	if cache.Source != source || time.Since(cache.FetchedAt) > RemoteCacheTTL || len(cache.Versions) == 0 {
+		return nil, false
+	}
+	return cache.Versions, true
+}
+
+func writeRemoteCache(source string, versions []string) {
+	data, err := json.MarshalIndent(remoteCache{
+		Source:    source,
+		FetchedAt: time.Now(),
+		Versions:  versions,
+	}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(utils.JfvmCache, 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(utils.JfvmCache, remoteCacheFile), data, 0644)
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// maxIndirections bounds alias/channel chains such as "prod" -> "stable" -> "^2.74".
+const maxIndirections = 5
+
+// exactVersionPattern is a semver release version. Prerelease and build
+// parts are limited to semver's characters so a version is always safe to
+// use as a single path element.
+var exactVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// ValidateVersion rejects anything that is not a release version, before
+// it is joined into paths under ~/.jfvm or into a download URL.
+func ValidateVersion(version string) error {
+	if !exactVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid version %q: expected a release version such as 2.74.0", version)
+	}
+	return nil
+}
+
+// Resolver turns version specs into concrete versions. A spec can be an
+// alias, an exact version or linked name, "latest", a channel defined in
+// settings.json, a partial version ("2.74") or a semver constraint
+// (">=2.70 <2.75", "^2.74.0", "2.7.x").
+type Resolver struct {
+	Source Source
+	// InstalledOnly never consults the remote release list, for commands
+	// that can only run versions already on disk.
+	InstalledOnly bool
+	// Settings are used for channels when set; otherwise settings.json is
+	// read on every Resolve.
+	Settings *utils.Settings
+}
+
+// Resolve returns the version a spec refers to. Installed versions are
+// preferred; the remote release list is only queried when nothing installed
+// matches.
+func (r Resolver) Resolve(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", fmt.Errorf("empty version")
+	}
+
+	var settings utils.Settings
+	if r.Settings != nil {
+		settings = *r.Settings
+	} else {
+		var err error
+		if settings, err = utils.LoadSettings(); err != nil {
+			return "", err
+		}
+	}
+
+	for i := 0; i < maxIndirections; i++ {
+		if alias, err := utils.ResolveAlias(spec); err == nil {
+			spec = strings.TrimSpace(alias)
+			continue
+		}
+		if channel, ok := settings.Channels[spec]; ok {
+			spec = strings.TrimSpace(channel)
+			continue
+		}
+		break
+	}
+
+	if utils.CheckVersionExists(spec) == nil {
+		return spec, nil
+	}
+
+	if spec == "latest" {
+		return r.latest()
+	}
+
+	if exactVersionPattern.MatchString(spec) {
+		return spec, nil
+	}
+
+	constraint, err := semver.NewConstraint(spec)
+	if err != nil {
+		// Not a version expression; let the caller report it as missing.
+		return spec, nil
+	}
+
+	installed, err := utils.InstalledVersions()
+	if err != nil {
+		return "", err
+	}
+	if v := firstMatch(SortVersions(installed), constraint); v != "" {
+		return v, nil
+	}
+
+	if r.InstalledOnly {
+		return "", fmt.Errorf("no installed version matches %q", spec)
+	}
+
+	remote, err := ListRemoteVersions(r.Source, false)
+	if err != nil {
+		return "", err
+	}
+	if v := firstMatch(remote, constraint); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("no released version matches %q", spec)
+}
+
+// latest returns the newest release, falling back to the newest installed
+// version when the release list is unavailable or not wanted.
+func (r Resolver) latest() (string, error) {
+	if !r.InstalledOnly {
+		remote, err := ListRemoteVersions(r.Source, false)
+		if err == nil {
+			return remote[0], nil
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  Could not query releases, using newest installed version: %v\n", err)
+	}
+
+	installed, err := utils.InstalledVersions()
+	if err != nil {
+		return "", err
+	}
+	sorted := SortVersions(installed)
+	if len(sorted) == 0 {
+		return "", fmt.Errorf("no installed versions to resolve 'latest'")
+	}
+	return sorted[0], nil
+}
+
+// firstMatch returns the first version in a newest-first list that satisfies
+// the constraint.
+func firstMatch(versions []string, constraint *semver.Constraints) string {
+	for _, name := range versions {
+		v, err := semver.NewVersion(name)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(v) {
+			return name
+		}
+	}
+	return ""
+}
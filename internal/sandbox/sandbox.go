@@ -0,0 +1,162 @@
+// Package sandbox gives each jf version a throwaway JFrog CLI home, seeded
+// from a snapshot of the user's configuration, so versions cannot migrate
+// or overwrite each other's config during a comparison.
+package sandbox
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+// skipped are JFrog CLI home entries that hold caches and logs rather than
+// configuration; they are not copied.
+var skipped = map[string]bool{
+	"backup":       true,
+	"dependencies": true,
+	"logs":         true,
+	"tmp":          true,
+}
+
+// Snapshot is a private copy of the JFrog CLI home taken once, so every
+// sandbox starts from the same configuration even if the real one changes
+// while versions run.
+type Snapshot struct {
+	Source string
+	dir    string
+}
+
+// Take copies the JFrog CLI home into a temporary directory under
+// ~/.jfvm/tmp. A missing home gives an empty snapshot.
+func Take() (*Snapshot, error) {
+	if err := os.MkdirAll(utils.JfvmStaging, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(utils.JfvmStaging, "snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	s := &Snapshot{Source: utils.JFrogCLIHome(), dir: dir}
+	if err := copyTree(s.Source, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to snapshot %s: %w", s.Source, err)
+	}
+	return s, nil
+}
+
+// Remove deletes the snapshot.
+func (s *Snapshot) Remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+// Sandbox is one version's throwaway JFrog CLI home, plus an empty working
+// directory when requested.
+type Sandbox struct {
+	Root    string
+	Home    string
+	WorkDir string
+}
+
+// New creates a sandbox seeded from the snapshot. name only labels the
+// directory.
+func (s *Snapshot) New(name string, workDir bool) (*Sandbox, error) {
+	root, err := os.MkdirTemp(utils.JfvmStaging, "sandbox-"+name+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+
+	b := &Sandbox{Root: root, Home: filepath.Join(root, "jfrog")}
+	if err := copyTree(s.dir, b.Home); err != nil {
+		b.Remove()
+		return nil, fmt.Errorf("failed to seed sandbox: %w", err)
+	}
+	if workDir {
+		b.WorkDir = filepath.Join(root, "work")
+		if err := os.Mkdir(b.WorkDir, 0700); err != nil {
+			b.Remove()
+			return nil, fmt.Errorf("failed to create sandbox working directory: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// Env returns the variables that point jf at the sandbox.
+func (b *Sandbox) Env() []string {
+	return []string{"JFROG_CLI_HOME_DIR=" + b.Home}
+}
+
+// Remove deletes the sandbox.
+func (b *Sandbox) Remove() error {
+	return os.RemoveAll(b.Root)
+}
+
+// copyTree copies src into dst (created 0700), keeping file modes and
+// symlinks and leaving out skipped top-level entries. A missing src
+// copies nothing.
+func copyTree(src, dst string) error {
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if filepath.Dir(rel) == "." && skipped[rel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, 0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		}
+		// Sockets, devices and the like are not configuration
+		return nil
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,279 @@
+// Package scenario loads suites of jf invocations that `jfvm compare` and
+// `jfvm benchmark` run in one go.
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a suite file. YAML and JSON use the same keys; settings at
+// the top level are defaults for every command.
+type Scenario struct {
+	Name       string            `yaml:"name"`
+	Parallel   int               `yaml:"parallel"`
+	Timeout    string            `yaml:"timeout"`
+	Iterations int               `yaml:"iterations"`
+	Warmup     *int              `yaml:"warmup"`
+	Mode       string            `yaml:"mode"`
+	Dir        string            `yaml:"dir"`
+	Env        map[string]string `yaml:"env"`
+	Ignore     Ignore            `yaml:"ignore"`
+	Commands   []Command         `yaml:"commands"`
+
+	timeout time.Duration
+}
+
+// Command is one jf invocation. Run is split like a shell command line (a
+// leading "jf" is optional); Args gives the arguments verbatim instead.
+type Command struct {
+	Name       string            `yaml:"name"`
+	Run        string            `yaml:"run"`
+	Args       []string          `yaml:"args"`
+	Env        map[string]string `yaml:"env"`
+	Dir        string            `yaml:"dir"`
+	Stdin      string            `yaml:"stdin"`
+	Input      *string           `yaml:"input"`
+	Timeout    string            `yaml:"timeout"`
+	ExpectExit *int              `yaml:"expect_exit"`
+	Iterations int               `yaml:"iterations"`
+	Warmup     *int              `yaml:"warmup"`
+	Mode       string            `yaml:"mode"`
+	Ignore     Ignore            `yaml:"ignore"`
+
+	timeout time.Duration
+}
+
+// Ignore lists masks for compare, as --ignore-regex and --ignore-path.
+type Ignore struct {
+	Regex []string `yaml:"regex"`
+	Paths []string `yaml:"paths"`
+}
+
+// Load reads and validates a scenario file. Relative dir and stdin paths
+// are resolved against the file's directory.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	var s Scenario
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := s.validate(base); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func (s *Scenario) validate(base string) error {
+	if len(s.Commands) == 0 {
+		return fmt.Errorf("no commands")
+	}
+	if s.Parallel < 0 || s.Iterations < 0 || (s.Warmup != nil && *s.Warmup < 0) {
+		return fmt.Errorf("parallel, iterations and warmup must not be negative")
+	}
+	if err := validMode(s.Mode); err != nil {
+		return err
+	}
+
+	var err error
+	if s.timeout, err = parseTimeout(s.Timeout); err != nil {
+		return err
+	}
+	s.Dir = resolvePath(base, s.Dir)
+
+	names := make(map[string]bool)
+	for i := range s.Commands {
+		c := &s.Commands[i]
+		where := fmt.Sprintf("command %d", i+1)
+		if c.Name != "" {
+			where = fmt.Sprintf("command %q", c.Name)
+		}
+
+		switch {
+		case c.Run != "" && len(c.Args) > 0:
+			return fmt.Errorf("%s: use either run or args", where)
+		case c.Run != "":
+			if c.Args, err = Split(c.Run); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+			if len(c.Args) > 0 && c.Args[0] == "jf" {
+				c.Args = c.Args[1:]
+			}
+		}
+		if len(c.Args) == 0 {
+			return fmt.Errorf("%s: no jf arguments", where)
+		}
+
+		if c.Name == "" {
+			c.Name = strings.Join(c.Args, " ")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate command name %q", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Stdin != "" && c.Input != nil {
+			return fmt.Errorf("%s: use either stdin or input", where)
+		}
+		if c.Iterations < 0 || (c.Warmup != nil && *c.Warmup < 0) {
+			return fmt.Errorf("%s: iterations and warmup must not be negative", where)
+		}
+		if err := validMode(c.Mode); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		if c.timeout, err = parseTimeout(c.Timeout); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		c.Dir = resolvePath(base, c.Dir)
+		c.Stdin = resolvePath(base, c.Stdin)
+	}
+	return nil
+}
+
+func validMode(mode string) error {
+	switch mode {
+	case "", "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q: use text or json", mode)
+}
+
+func parseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	return d, nil
+}
+
+func resolvePath(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// CommandTimeout returns the command's timeout, else the scenario's, else
+// fallback.
+func (s *Scenario) CommandTimeout(c Command, fallback time.Duration) time.Duration {
+	switch {
+	case c.timeout > 0:
+		return c.timeout
+	case s.timeout > 0:
+		return s.timeout
+	}
+	return fallback
+}
+
+// CommandIterations returns the command's benchmark iterations, else the
+// scenario's, else fallback.
+func (s *Scenario) CommandIterations(c Command, fallback int) int {
+	switch {
+	case c.Iterations > 0:
+		return c.Iterations
+	case s.Iterations > 0:
+		return s.Iterations
+	}
+	return fallback
+}
+
+// CommandWarmup returns the command's benchmark warmup runs, else the
+// scenario's, else fallback. Unlike iterations, an explicit 0 applies.
+func (s *Scenario) CommandWarmup(c Command, fallback int) int {
+	switch {
+	case c.Warmup != nil:
+		return *c.Warmup
+	case s.Warmup != nil:
+		return *s.Warmup
+	}
+	return fallback
+}
+
+// CommandMode returns the command's compare mode, else the scenario's, else
+// fallback.
+func (s *Scenario) CommandMode(c Command, fallback string) string {
+	switch {
+	case c.Mode != "":
+		return c.Mode
+	case s.Mode != "":
+		return s.Mode
+	}
+	return fallback
+}
+
+// CommandIgnore merges the scenario's ignore rules with the command's.
+func (s *Scenario) CommandIgnore(c Command) Ignore {
+	return Ignore{
+		Regex: append(append([]string(nil), s.Ignore.Regex...), c.Ignore.Regex...),
+		Paths: append(append([]string(nil), s.Ignore.Paths...), c.Ignore.Paths...),
+	}
+}
+
+// CommandDir returns the command's working directory, else the
+// scenario's; empty means the current directory.
+func (s *Scenario) CommandDir(c Command) string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	return s.Dir
+}
+
+// CommandEnv returns the scenario and command variables as sorted
+// KEY=VALUE pairs, command values winning. $VAR references in values are
+// expanded from the caller's environment.
+func (s *Scenario) CommandEnv(c Command) []string {
+	merged := make(map[string]string, len(s.Env)+len(c.Env))
+	for k, v := range s.Env {
+		merged[k] = v
+	}
+	for k, v := range c.Env {
+		merged[k] = v
+	}
+
+	env := make([]string, 0, len(merged))
+	for k, v := range merged {
+		env = append(env, k+"="+os.ExpandEnv(v))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// StdinData returns what the command reads on stdin: the stdin file, the
+// inline input, or nil for none.
+func (c Command) StdinData() ([]byte, error) {
+	if c.Input != nil {
+		return []byte(*c.Input), nil
+	}
+	if c.Stdin == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(c.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin for %s: %w", c.Name, err)
+	}
+	return data, nil
+}
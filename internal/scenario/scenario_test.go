@@ -0,0 +1,164 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScenario(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeScenario(t, dir, "upload.txt", "payload")
+	t.Setenv("JFVM_TEST_REPO", "libs-local")
+
+	// Loaded by a relative path, so paths resolve against its absolute
+	// directory rather than the working directory
+	t.Chdir(dir)
+	writeScenario(t, dir, "smoke.yaml", `
+timeout: 30s
+iterations: 5
+warmup: 2
+mode: text
+dir: work
+env:
+  JFROG_CLI_LOG_LEVEL: ERROR
+  REPO: generic
+ignore:
+  regex: ['\d+ms']
+commands:
+  - run: jf rt ping
+    timeout: 5s
+    warmup: 0
+  - name: upload
+    run: rt u "a b.txt" $REPO/
+    dir: /abs
+    stdin: upload.txt
+    mode: json
+    env:
+      REPO: $JFVM_TEST_REPO
+    ignore:
+      paths: [$.created]
+    expect_exit: 0
+  - args: [c, show, "--format=json"]
+    input: ""
+    iterations: 2
+`)
+
+	s, err := Load("smoke.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "smoke" {
+		t.Errorf("name = %q, want the file name", s.Name)
+	}
+	if s.Dir != filepath.Join(dir, "work") {
+		t.Errorf("dir = %q, want it next to the scenario", s.Dir)
+	}
+
+	ping, upload, show := s.Commands[0], s.Commands[1], s.Commands[2]
+	if ping.Name != "rt ping" || !reflect.DeepEqual(ping.Args, []string{"rt", "ping"}) {
+		t.Errorf("ping = %q %q, want the leading jf dropped", ping.Name, ping.Args)
+	}
+	if !reflect.DeepEqual(upload.Args, []string{"rt", "u", "a b.txt", "$REPO/"}) {
+		t.Errorf("upload args = %q", upload.Args)
+	}
+	if upload.Dir != "/abs" || upload.Stdin != filepath.Join(dir, "upload.txt") {
+		t.Errorf("upload dir/stdin = %q/%q", upload.Dir, upload.Stdin)
+	}
+	if upload.ExpectExit == nil || *upload.ExpectExit != 0 {
+		t.Errorf("expect_exit = %v, want 0", upload.ExpectExit)
+	}
+
+	if got := s.CommandTimeout(ping, time.Minute); got != 5*time.Second {
+		t.Errorf("ping timeout = %v, want its own 5s", got)
+	}
+	if got := s.CommandTimeout(upload, time.Minute); got != 30*time.Second {
+		t.Errorf("upload timeout = %v, want the scenario's 30s", got)
+	}
+	if got := []int{s.CommandIterations(ping, 1), s.CommandIterations(show, 1)}; !reflect.DeepEqual(got, []int{5, 2}) {
+		t.Errorf("iterations = %v, want 5 and 2", got)
+	}
+	if got := []int{s.CommandWarmup(ping, 1), s.CommandWarmup(upload, 1)}; !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("warmup = %v, want an explicit 0 and the scenario's 2", got)
+	}
+	if got := []string{s.CommandMode(ping, "auto"), s.CommandMode(upload, "auto")}; !reflect.DeepEqual(got, []string{"text", "json"}) {
+		t.Errorf("modes = %v", got)
+	}
+	if got := []string{s.CommandDir(ping), s.CommandDir(upload)}; !reflect.DeepEqual(got, []string{filepath.Join(dir, "work"), "/abs"}) {
+		t.Errorf("dirs = %v", got)
+	}
+	if got, want := s.CommandIgnore(upload), (Ignore{Regex: []string{`\d+ms`}, Paths: []string{"$.created"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ignore = %+v, want %+v", got, want)
+	}
+	if got, want := s.CommandEnv(upload), []string{"JFROG_CLI_LOG_LEVEL=ERROR", "REPO=libs-local"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("env = %q, want %q", got, want)
+	}
+
+	if data, err := upload.StdinData(); err != nil || string(data) != "payload" {
+		t.Errorf("upload stdin = %q, %v", data, err)
+	}
+	if data, err := show.StdinData(); err != nil || data == nil || len(data) != 0 {
+		t.Errorf("show stdin = %q, %v; want empty input, not none", data, err)
+	}
+	if data, err := ping.StdinData(); err != nil || data != nil {
+		t.Errorf("ping stdin = %q, %v; want none", data, err)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := writeScenario(t, t.TempDir(), "suite.json",
+		`{"name": "nightly", "commands": [{"args": ["rt", "ping"], "expect_exit": 1}]}`)
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "nightly" || s.Commands[0].Name != "rt ping" || *s.Commands[0].ExpectExit != 1 {
+		t.Errorf("scenario = %+v", s)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"no commands", "name: empty\n", "no commands"},
+		{"unknown key", "commands:\n  - run: rt ping\nparalel: 2\n", "field paralel not found"},
+		{"unknown command key", "commands:\n  - run: rt ping\n    expect_exit_code: 1\n", "field expect_exit_code not found"},
+		{"negative parallel", "parallel: -1\ncommands:\n  - run: rt ping\n", "must not be negative"},
+		{"bad mode", "mode: xml\ncommands:\n  - run: rt ping\n", `unknown mode "xml"`},
+		{"bad timeout", "timeout: soon\ncommands:\n  - run: rt ping\n", `invalid timeout "soon"`},
+		{"zero timeout", "commands:\n  - run: rt ping\n    timeout: 0s\n", `command 1: invalid timeout "0s"`},
+		{"run and args", "commands:\n  - run: rt ping\n    args: [rt, ping]\n", "command 1: use either run or args"},
+		{"only jf", "commands:\n  - run: jf\n", "command 1: no jf arguments"},
+		{"bad quoting", "commands:\n  - name: up\n    run: rt u 'a.txt\n", `command "up": unterminated ' quote`},
+		{"duplicate names", "commands:\n  - run: rt ping\n  - args: [rt, ping]\n", `duplicate command name "rt ping"`},
+		{"stdin and input", "commands:\n  - run: rt ping\n    stdin: in.txt\n    input: x\n", "use either stdin or input"},
+		{"negative warmup", "commands:\n  - run: rt ping\n    warmup: -1\n", "command 1: iterations and warmup must not be negative"},
+		{"command mode", "commands:\n  - run: rt ping\n    mode: yaml\n", `command 1: unknown mode "yaml"`},
+		{"not yaml", "commands: [\n", "failed to parse scenario"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeScenario(t, t.TempDir(), "suite.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read scenario") {
+		t.Errorf("missing file: error = %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"   \t\n ", nil},
+		{"rt ping", []string{"rt", "ping"}},
+		{"  rt\tping\n--server-id  prod ", []string{"rt", "ping", "--server-id", "prod"}},
+		{`rt u 'a b.txt' repo/`, []string{"rt", "u", "a b.txt", "repo/"}},
+		{`rt s "my repo/*"`, []string{"rt", "s", "my repo/*"}},
+		{`'single \ and "double"'`, []string{`single \ and "double"`}},
+		{`"say \"hi\" \\ \n"`, []string{`say "hi" \ \n`}},
+		{`a\ b c\"d \'e\\`, []string{"a b", `c"d`, "'e\\"}},
+		{`--props="a=1;b=2"x`, []string{"--props=a=1;b=2x"}},
+		{`"" ''`, []string{"", ""}},
+		{`pre'mid'"end"`, []string{"premidend"}},
+		{"$HOME *.zip", []string{"$HOME", "*.zip"}},
+		{`"été" naïve`, []string{"été", "naïve"}},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.line)
+		if err != nil {
+			t.Errorf("Split(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{`rt ping \`, "trailing backslash"},
+		{`rt u 'a b.txt`, "unterminated ' quote"},
+		{`rt s "repo`, `unterminated " quote`},
+		{`"ends in escape\"`, `unterminated " quote`},
+	}
+	for _, tt := range tests {
+		_, err := Split(tt.line)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Split(%q) error = %v, want %q", tt.line, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bhanurp/jfvm/cmd/utils"
+)
+
+const (
+	DefaultReleasesURL = "https://releases.jfrog.io/artifactory/jfrog-cli"
+
+	// Release layouts relative to the source base URL. {version}, {platform}
+	// and {binary} are substituted when building a download URL.
+	LayoutV2 = "v2-jf/{version}/jfrog-cli-{platform}/jf"
+	LayoutV1 = "v1/{version}/jfrog-cli-{platform}/jfrog"
+)
+
+// Source describes where release binaries are downloaded from.
+type Source struct {
+	BaseURL  string
+	Layout   string
+	Username string
+	Password string
+	Token    string
+}
+
+// ResolveSource picks the download source. The mirror argument (usually the
+// --mirror flag) wins over JFVM_MIRROR, which wins over settings.json.
+func ResolveSource(mirror string) (Source, error) {
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		return Source{}, err
+	}
+	return ResolveSourceWith(settings, mirror)
+}
+
+// ResolveSourceWith is ResolveSource with settings already loaded.
+func ResolveSourceWith(settings utils.Settings, mirror string) (Source, error) {
+	src := Source{
+		BaseURL:  DefaultReleasesURL,
+		Layout:   settings.Mirror.Layout,
+		Username: settings.Mirror.Username,
+		Password: settings.Mirror.Password,
+		Token:    settings.Mirror.Token,
+	}
+	if settings.Mirror.URL != "" {
+		src.BaseURL = settings.Mirror.URL
+	}
+
+	if v := os.Getenv("JFVM_MIRROR"); v != "" {
+		src.BaseURL = v
+	}
+	if v := os.Getenv("JFVM_MIRROR_LAYOUT"); v != "" {
+		src.Layout = v
+	}
+	if v := os.Getenv("JFVM_MIRROR_USER"); v != "" {
+		src.Username = v
+	}
+	if v := os.Getenv("JFVM_MIRROR_PASSWORD"); v != "" {
+		src.Password = v
+	}
+	if v := os.Getenv("JFVM_MIRROR_TOKEN"); v != "" {
+		src.Token = v
+	}
+
+	if mirror != "" {
+		src.BaseURL = mirror
+	}
+
+	return src.normalize()
+}
+
+// normalize expands layout presets and moves credentials embedded in the
+// base URL into the auth fields so they never end up in printed URLs.
+func (s Source) normalize() (Source, error) {
+	switch s.Layout {
+	case "", "v2":
+		s.Layout = LayoutV2
+	case "v1":
+		s.Layout = LayoutV1
+	}
+	if !strings.Contains(s.Layout, "{version}") {
+		return s, fmt.Errorf("mirror layout %q must contain {version}", s.Layout)
+	}
+
+	u, err := url.Parse(strings.TrimRight(s.BaseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return s, fmt.Errorf("invalid mirror URL: %q", s.BaseURL)
+	}
+	if u.User != nil {
+		if s.Username == "" {
+			s.Username = u.User.Username()
+		}
+		if p, ok := u.User.Password(); ok && s.Password == "" {
+			s.Password = p
+		}
+		u.User = nil
+	}
+	s.BaseURL = u.String()
+
+	return s, nil
+}
+
+// BinaryURL returns the download URL of a release for the given platform.
+func (s Source) BinaryURL(version, platform string) string {
+	path := strings.NewReplacer(
+		"{version}", version,
+		"{platform}", platform,
+		"{binary}", utils.BinaryName,
+	).Replace(s.Layout)
+	return s.BaseURL + "/" + strings.TrimLeft(path, "/")
+}
+
+// Get performs an authenticated GET against the source.
+func (s Source) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s.Token != "":
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	case s.Username != "":
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	return http.DefaultClient.Do(req)
+}
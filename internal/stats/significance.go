@@ -0,0 +1,205 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// MeanCI returns the two-sided confidence interval for the mean of values
+// at the given level (e.g. 0.95), using Student's t distribution. With
+// fewer than two values both bounds are the mean.
+func MeanCI(values []float64, level float64) (low, high float64) {
+	mean := Mean(values)
+	n := len(values)
+	if n < 2 {
+		return mean, mean
+	}
+	margin := TQuantile(1-(1-level)/2, float64(n-1)) * StdDev(values) / math.Sqrt(float64(n))
+	return mean - margin, mean + margin
+}
+
+// Welch runs Welch's unequal-variances t-test and returns the two-sided
+// p-value that a and b have the same mean. It returns 1 when either side
+// has fewer than two values, and 0 or 1 when both are constant.
+func Welch(a, b []float64) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 1
+	}
+	va := variance(a) / float64(len(a))
+	vb := variance(b) / float64(len(b))
+	diff := Mean(a) - Mean(b)
+	if va+vb == 0 {
+		if diff == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	t := diff / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) /
+		(va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	return tTwoTailed(t, df)
+}
+
+// MannWhitney runs the Mann-Whitney U test and returns the two-sided
+// p-value that a and b come from the same distribution. It uses the normal
+// approximation with tie and continuity corrections, and returns 1 when
+// either side is empty or every value is tied.
+func MannWhitney(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 1
+	}
+	u, sigma := mannWhitneyU(a, b)
+	if sigma == 0 {
+		return 1
+	}
+	mean := float64(len(a)) * float64(len(b)) / 2
+	z := (math.Abs(u-mean) - 0.5) / sigma
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
+
+// mannWhitneyU returns U for a and the standard deviation of U under the
+// null hypothesis, corrected for ties. Tied values share their average rank.
+func mannWhitneyU(a, b []float64) (u, sigma float64) {
+	type sample struct {
+		value float64
+		fromA bool
+	}
+	all := make([]sample, 0, len(a)+len(b))
+	for _, v := range a {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range b {
+		all = append(all, sample{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u = rankSumA - n1*(n1+1)/2
+	sigma = math.Sqrt(n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1))))
+	return u, sigma
+}
+
+// TQuantile returns the p-th quantile of Student's t distribution with df
+// degrees of freedom.
+func TQuantile(p, df float64) float64 {
+	if p == 0.5 {
+		return 0
+	}
+	if p < 0.5 {
+		return -TQuantile(1-p, df)
+	}
+	// Bisect on the upper tail, which falls monotonically in t
+	target := 2 * (1 - p)
+	low, high := 0.0, 1.0
+	for tTwoTailed(high, df) > target {
+		high *= 2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if tTwoTailed(mid, df) > target {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// variance is the sample variance (n-1 denominator).
+func variance(values []float64) float64 {
+	sd := StdDev(values)
+	return sd * sd
+}
+
+// tTwoTailed is P(|T| >= |t|) for Student's t with df degrees of freedom.
+func tTwoTailed(t, df float64) float64 {
+	return regIncBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// regIncBeta is the regularized incomplete beta function I_x(a, b),
+// evaluated with Lentz's continued fraction.
+func regIncBeta(a, b, x float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	}
+	lbeta, _ := math.Lgamma(a + b)
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	front := math.Exp(lbeta - la - lb + a*math.Log(x) + b*math.Log(1-x))
+
+	// The fraction converges quickly only below the mean; use symmetry above it
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaFraction(b, a, 1-x)/b
+	}
+	return front * betaFraction(a, b, x) / a
+}
+
+func betaFraction(a, b, x float64) float64 {
+	const (
+		epsilon = 1e-14
+		tiny    = 1e-300
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	f := d
+	for m := 1.0; m <= 300; m++ {
+		// Even step
+		num := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		f *= d * c
+
+		// Odd step
+		num = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		f *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return f
+}
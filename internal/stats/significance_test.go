@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference values are from Student's t tables, or from integrating the t
+// density numerically for the Welch p-values.
+
+func approx(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestTQuantile(t *testing.T) {
+	tests := []struct {
+		p, df, want float64
+	}{
+		{0.975, 10, 2.228139},
+		{0.975, 1, 12.706205},
+		{0.95, 5, 2.015048},
+		{0.995, 30, 2.749996},
+		{0.975, 1e6, 1.959966},
+		{0.025, 10, -2.228139},
+		{0.5, 4, 0},
+	}
+	for _, tt := range tests {
+		if got := TQuantile(tt.p, tt.df); !approx(got, tt.want, 1e-5) {
+			t.Errorf("TQuantile(%v, %v) = %.6f, want %.6f", tt.p, tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestMeanCI(t *testing.T) {
+	// 12 ± t(0.975, 4) · sqrt(2.5 / 5)
+	low, high := MeanCI([]float64{10, 11, 12, 13, 14}, 0.95)
+	if !approx(low, 10.036757, 1e-5) || !approx(high, 13.963243, 1e-5) {
+		t.Errorf("MeanCI = [%.6f, %.6f], want [10.036757, 13.963243]", low, high)
+	}
+	if low, high := MeanCI([]float64{7}, 0.95); low != 7 || high != 7 {
+		t.Errorf("MeanCI of one value = [%v, %v], want [7, 7]", low, high)
+	}
+}
+
+func TestWelch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		// t = -2, df = 8
+		{"equal variances", []float64{10, 11, 12, 13, 14}, []float64{12, 13, 14, 15, 16}, 0.080516},
+		// t = -2.376354, df = 6.972256
+		{"unequal variances and sizes", []float64{1, 2, 3, 4, 5}, []float64{2, 4, 6, 8, 10, 12}, 0.049284},
+		{"same samples", []float64{1, 2, 3}, []float64{1, 2, 3}, 1},
+		{"too few values", []float64{1}, []float64{5, 6}, 1},
+		{"constant and different", []float64{2, 2}, []float64{3, 3}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Welch(tt.a, tt.b); !approx(got, tt.want, 1e-5) {
+				t.Errorf("Welch = %.6f, want %.6f", got, tt.want)
+			}
+			if got, swapped := Welch(tt.a, tt.b), Welch(tt.b, tt.a); !approx(got, swapped, 1e-12) {
+				t.Errorf("Welch is not symmetric: %v, %v", got, swapped)
+			}
+		})
+	}
+}
+
+func TestMannWhitneyWithTies(t *testing.T) {
+	// Ranks: 1 2 4 4 | 4 6 | 7.5 7.5 | 9 10, so R(a) = 18.5 and
+	// U = 18.5 - 5·6/2 = 3.5. Ties of 3 and 2 give
+	// σ = sqrt(25/12 · (11 - 30/90)).
+	a := []float64{1, 2, 3, 3, 5}
+	b := []float64{3, 4, 5, 6, 7}
+
+	u, sigma := mannWhitneyU(a, b)
+	if u != 3.5 {
+		t.Errorf("U = %v, want 3.5", u)
+	}
+	if !approx(sigma, 4.714045, 1e-6) {
+		t.Errorf("σ = %.6f, want 4.714045", sigma)
+	}
+	if u, _ := mannWhitneyU(b, a); u != 21.5 {
+		t.Errorf("U for b = %v, want 21.5 (n1·n2 - 3.5)", u)
+	}
+
+	// z = (|3.5 - 12.5| - 0.5) / σ
+	if p := MannWhitney(a, b); !approx(p, 0.071369, 1e-6) {
+		t.Errorf("MannWhitney = %.6f, want 0.071369", p)
+	}
+}
+
+func TestMannWhitneyDegenerate(t *testing.T) {
+	if p := MannWhitney(nil, []float64{1, 2}); p != 1 {
+		t.Errorf("empty side: p = %v, want 1", p)
+	}
+	if p := MannWhitney([]float64{4, 4}, []float64{4, 4, 4}); p != 1 {
+		t.Errorf("all tied: p = %v, want 1", p)
+	}
+	// Completely separated samples of 10 are clearly different
+	var a, b []float64
+	for i := 0; i < 10; i++ {
+		a = append(a, float64(i))
+		b = append(b, float64(i+100))
+	}
+	if p := MannWhitney(a, b); p >= 0.001 {
+		t.Errorf("separated samples: p = %v, want < 0.001", p)
+	}
+}
@@ -0,0 +1,94 @@
+// Package stats holds the descriptive statistics used by history analytics
+// and benchmarks.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Sorted returns a sorted copy of values.
+func Sorted(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// Percentile returns the p-th percentile (0-100) of sorted values using
+// linear interpolation between closest ranks. It returns 0 for no values.
+func Percentile(sorted []float64, p float64) float64 {
+	switch len(sorted) {
+	case 0:
+		return 0
+	case 1:
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo < 0 {
+		return sorted[0]
+	}
+	if hi >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// Median returns the 50th percentile of sorted values.
+func Median(sorted []float64) float64 {
+	return Percentile(sorted, 50)
+}
+
+// Mean returns the arithmetic mean, or 0 for no values.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the sample standard deviation (n-1 denominator), or 0
+// for fewer than two values.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// RemoveOutliers splits sorted values into those inside Tukey's fences,
+// [Q1 - 1.5·IQR, Q3 + 1.5·IQR], and those outside. Fewer than four values
+// are all kept, since their quartiles say little.
+func RemoveOutliers(sorted []float64) (kept, outliers []float64) {
+	if len(sorted) < 4 {
+		return sorted, nil
+	}
+	q1, q3 := Percentile(sorted, 25), Percentile(sorted, 75)
+	low, high := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	for _, v := range sorted {
+		if v < low || v > high {
+			outliers = append(outliers, v)
+		} else {
+			kept = append(kept, v)
+		}
+	}
+	return kept, outliers
+}
+
+// Ratio returns part/total, or 0 when total is 0.
+func Ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,56 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		p, want float64
+	}{
+		{0, 1},
+		{25, 2},
+		{50, 3},
+		{90, 4.6},
+		{100, 5},
+	}
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); !approx(got, tt.want, 1e-12) {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Median([]float64{1, 2, 3, 10}); got != 2.5 {
+		t.Errorf("Median of an even count = %v, want 2.5", got)
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile of no values = %v, want 0", got)
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	// Sample standard deviation: sum of squares 32 over n-1 = 7
+	if got := StdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9}); !approx(got, 2.138090, 1e-6) {
+		t.Errorf("StdDev = %.6f, want 2.138090", got)
+	}
+	if got := StdDev([]float64{3}); got != 0 {
+		t.Errorf("StdDev of one value = %v, want 0", got)
+	}
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	// Q1 = 11, Q3 = 14, so the fences are 6.5 and 18.5
+	kept, outliers := RemoveOutliers([]float64{1, 10, 11, 12, 12, 13, 14, 15, 40})
+	if want := []float64{10, 11, 12, 12, 13, 14, 15}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept = %v, want %v", kept, want)
+	}
+	if want := []float64{1, 40}; !reflect.DeepEqual(outliers, want) {
+		t.Errorf("outliers = %v, want %v", outliers, want)
+	}
+
+	small := []float64{1, 2, 100}
+	if kept, outliers := RemoveOutliers(small); !reflect.DeepEqual(kept, small) || outliers != nil {
+		t.Errorf("fewer than four values: kept %v, outliers %v; want all kept", kept, outliers)
+	}
+}
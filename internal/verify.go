@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	SignatureNone   = ""
+	SignatureGPG    = "gpg"
+	SignatureCosign = "cosign"
+)
+
+// fetchChecksum downloads the SHA-256 published next to a release binary.
+// Artifactory serves it at "<artifact>.sha256"; the file may contain just the
+// digest or "<digest>  <filename>".
+func fetchChecksum(src Source, url string) (string, error) {
+	resp, err := src.Get(url + ".sha256")
+	if err != nil {
+		return "", fmt.Errorf("checksum request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch checksum: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	return parseChecksum(string(data))
+}
+
+func parseChecksum(data string) (string, error) {
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum")
+	}
+	sum := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("malformed SHA-256 checksum: %q", fields[0])
+	}
+	return sum, nil
+}
+
+func verifyChecksum(expected, actual string) error {
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// verifySignature downloads the detached signature for a release and checks
+// it with the external gpg or cosign tool.
+func verifySignature(src Source, mode, key, url, binPath string) error {
+	var suffix string
+	switch mode {
+	case SignatureGPG:
+		suffix = ".asc"
+	case SignatureCosign:
+		suffix = ".sig"
+		if key == "" {
+			return fmt.Errorf("cosign verification requires a public key (--signature-key)")
+		}
+	default:
+		return fmt.Errorf("unsupported signature mode: %s", mode)
+	}
+
+	sigPath := filepath.Join(filepath.Dir(binPath), filepath.Base(binPath)+suffix)
+	if err := downloadFile(src, url+suffix, sigPath); err != nil {
+		return fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer os.Remove(sigPath)
+
+	var cmd *exec.Cmd
+	switch mode {
+	case SignatureGPG:
+		args := []string{"--batch", "--verify"}
+		if key != "" {
+			args = []string{"--batch", "--no-default-keyring", "--keyring", key, "--verify"}
+		}
+		cmd = exec.Command("gpg", append(args, sigPath, binPath)...)
+	case SignatureCosign:
+		cmd = exec.Command("cosign", "verify-blob", "--key", key, "--signature", sigPath, binPath)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s signature verification failed: %w\n%s", mode, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func downloadFile(src Source, url, path string) error {
+	resp, err := src.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
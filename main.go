@@ -17,7 +17,9 @@ func main() {
 		Commands: []*cli.Command{
 			cmd.Install,
 			cmd.Use,
+			cmd.Shell,
 			cmd.List,
+			cmd.LsRemote,
 			cmd.Remove,
 			cmd.Clear,
 			cmd.Alias,
@@ -0,0 +1,42 @@
+#!/usr/bin/env bash
+# Measures the per-invocation overhead of the jf shim, with history
+# recording (fork/wait/record) and with JFVM_NO_HISTORY=1 (execve).
+#
+# Usage: scripts/bench_shim.sh [iterations]
+set -euo pipefail
+
+ITERATIONS="${1:-200}"
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+WORK="$(mktemp -d)"
+trap 'rm -rf "$WORK"' EXIT
+
+# Isolated jfvm home with a trivial jf so only shim overhead is measured
+export HOME="$WORK/home"
+mkdir -p "$HOME/.jfvm/versions/bench"
+printf '#!/bin/sh\nexit 0\n' > "$HOME/.jfvm/versions/bench/jf"
+chmod +x "$HOME/.jfvm/versions/bench/jf"
+echo "bench" > "$HOME/.jfvm/config"
+
+(cd "$ROOT/shim" && go build -o "$WORK/jf" .)
+
+now_ns() {
+  date +%s%N
+}
+
+measure() {
+  local label="$1"
+  shift
+  local start end
+  start=$(now_ns)
+  for ((i = 0; i < ITERATIONS; i++)); do
+    "$@" > /dev/null
+  done
+  end=$(now_ns)
+  awk -v label="$label" -v ns="$((end - start))" -v n="$ITERATIONS" \
+    'BEGIN { printf "%-28s %8.2f ms/invocation\n", label, ns / n / 1000000 }'
+}
+
+echo "🏁 Shim overhead over $ITERATIONS invocations"
+measure "direct jf" "$HOME/.jfvm/versions/bench/jf" --version
+measure "shim (history)" env JFVM_NO_HISTORY=0 "$WORK/jf" --version
+measure "shim (JFVM_NO_HISTORY=1)" env JFVM_NO_HISTORY=1 "$WORK/jf" --version
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// execReplace replaces the shim process with bin. It only returns on error.
+func execReplace(bin string, args []string) error {
+	return syscall.Exec(bin, append([]string{bin}, args...), os.Environ())
+}
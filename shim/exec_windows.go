@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "errors"
+
+// execReplace is not available on Windows; the caller runs jf as a child
+// process instead.
+func execReplace(bin string, args []string) error {
+	return errors.New("exec is not supported on windows")
+}
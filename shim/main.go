@@ -1,126 +1,245 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
-)
 
-type HistoryEntry struct {
-	Version   string    `json:"version"`
-	Timestamp time.Time `json:"timestamp"`
-	Command   string    `json:"command,omitempty"`
-	Duration  int64     `json:"duration_ms,omitempty"`
-	ExitCode  int       `json:"exit_code,omitempty"`
-	Stdout    string    `json:"stdout,omitempty"`
-	Stderr    string    `json:"stderr,omitempty"`
-}
+	"github.com/bhanurp/jfvm/cmd/utils"
+	"github.com/bhanurp/jfvm/internal"
+	"github.com/bhanurp/jfvm/internal/history"
+	"github.com/mattn/go-isatty"
+)
 
 func main() {
-	home := os.Getenv("HOME")
-	configPath := filepath.Join(home, ".jfvm", "config")
-	data, err := os.ReadFile(configPath)
+	// Read settings.json once; everything below gets this copy
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[shim] %v\n", err)
+		os.Exit(1)
+	}
+
+	spec, source, err := versionSpec(settings)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "No current version set. Run `jfvm use <version>` first.\n")
 		os.Exit(1)
 	}
 
-	version := strings.TrimSpace(string(data))
-	bin := filepath.Join(home, ".jfvm", "versions", version, "jf")
+	version, err := ensureVersion(spec, settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[shim] Cannot use version %q from %s: %v\n", spec, source, err)
+		os.Exit(1)
+	}
+
+	bin := filepath.Join(utils.JfvmVersions, version, utils.BinaryName)
 
 	// Only print debug info if JFVM_DEBUG is set
 	if os.Getenv("JFVM_DEBUG") != "" {
+		fmt.Printf("[shim] Version source: %s\n", source)
 		fmt.Printf("[shim] Executing version: %s\n", version)
 		fmt.Printf("[shim] Full binary path: %s\n", bin)
 	}
 
+	record := !historyDisabled(settings)
+	if !record {
+		// Nothing to record: become jf so there is no extra process and
+		// signals and exit codes reach the caller untouched. Falls back to
+		// running a child where exec is unsupported.
+		_ = execReplace(bin, os.Args[1:])
+	}
+
 	// Record start time for history
 	startTime := time.Now()
 	command := strings.Join(os.Args[1:], " ")
 
-	// Create buffers to capture output
-	var stdout, stderr bytes.Buffer
-
+	// Stream output live. Non-terminal streams are also teed into bounded
+	// buffers for history; terminals are handed to jf directly so it keeps
+	// its TTY detection (progress bars, interactive prompts).
 	cmd := exec.Command(bin, os.Args[1:]...)
 	cmd.Stdin = os.Stdin
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	var (
+		stdout, stderr                 *ringBuffer
+		stdoutCaptured, stderrCaptured bool
+	)
+	if policy, err := history.PolicyFrom(settings.History); record && err == nil && policy.CaptureOutput {
+		stdout = newRingBuffer(history.MaxOutputSize)
+		stderr = newRingBuffer(history.MaxOutputSize)
+		cmd.Stdout, stdoutCaptured = passthrough(os.Stdout, stdout)
+		cmd.Stderr, stderrCaptured = passthrough(os.Stderr, stderr)
+	}
 
-	// Capture output while also writing to the original streams
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err = cmd.Run()
+	err = run(cmd)
 	duration := time.Since(startTime)
 
-	// Write captured output to original streams
-	os.Stdout.Write(stdout.Bytes())
-	os.Stderr.Write(stderr.Bytes())
+	exitCode := exitCodeOf(err)
+
+	// Record history entry (silently fail if there's an issue)
+	if record {
+		entry := history.Entry{
+			Version:  version,
+			Command:  command,
+			Source:   source,
+			Duration: duration.Milliseconds(),
+			ExitCode: exitCode,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+			Args:     os.Args[1:],
+
+			StdoutCaptured: stdoutCaptured,
+			StderrCaptured: stderrCaptured,
+		}
+		if spec != version {
+			entry.Spec = spec
+		}
+		entry.CaptureContext(os.Args[1:])
+		_ = history.RecordWith(entry, settings)
+	}
 
-	// Get exit code
-	exitCode := 0
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			exitCode = exitError.ExitCode()
-		} else {
-			exitCode = 1
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Fprintf(os.Stderr, "[shim] Error executing binary: %v\n", err)
 		}
+		os.Exit(exitCode)
 	}
+}
 
-	// Record history entry (silently fail if there's an issue)
-	addHistoryEntry(home, version, command, duration, exitCode, stdout.String(), stderr.String())
+// run starts cmd and forwards termination signals sent to the shim until it
+// exits. Interrupts from the terminal are absorbed, since jf receives them
+// itself, so the shim survives to record history.
+func run(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
 
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitError.ExitCode())
+	forward := make(chan os.Signal, 1)
+	absorb := make(chan os.Signal, 1)
+	if len(forwardedSignals) > 0 {
+		signal.Notify(forward, forwardedSignals...)
+	}
+	signal.Notify(absorb, absorbedSignals...)
+	defer signal.Stop(forward)
+	defer signal.Stop(absorb)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-forward:
+				_ = cmd.Process.Signal(sig)
+			case <-absorb:
+			case <-done:
+				return
+			}
 		}
-		fmt.Fprintf(os.Stderr, "[shim] Error executing binary: %v\n", err)
-		os.Exit(1)
+	}()
+
+	return cmd.Wait()
+}
+
+// passthrough returns the writer jf should use for a stream, and whether
+// the stream is captured: a terminal is handed over uncaptured, anything
+// else is teed into the capture buffer.
+func passthrough(f *os.File, capture io.Writer) (io.Writer, bool) {
+	if isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()) {
+		return f, false
 	}
+	return io.MultiWriter(f, capture), true
 }
 
-func addHistoryEntry(home, version, command string, duration time.Duration, exitCode int, stdout, stderr string) {
-	historyFile := filepath.Join(home, ".jfvm", "history.json")
+// exitCodeOf maps the result of running jf to the shim's exit code. A child
+// killed by a signal yields 128+signal, as a shell would report.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	exitError, ok := err.(*exec.ExitError)
+	if !ok {
+		return 1
+	}
+	if status, ok := exitError.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitError.ExitCode()
+}
 
-	// Load existing history
-	var entries []HistoryEntry
-	if data, err := os.ReadFile(historyFile); err == nil {
-		// Ignore errors, start fresh if corrupted
-		json.Unmarshal(data, &entries)
+// versionSpec returns the requested version and where it came from, in order
+// of precedence: JFVM_VERSION (see `jfvm shell`), the nearest .jfrog-version
+// above the working directory, then the global config written by `jfvm use`.
+func versionSpec(settings utils.Settings) (spec, source string, err error) {
+	if v := strings.TrimSpace(os.Getenv("JFVM_VERSION")); v != "" {
+		return v, "JFVM_VERSION", nil
 	}
 
-	// Truncate output to prevent huge history files
-	const maxOutputSize = 5000
-	if len(stdout) > maxOutputSize {
-		stdout = stdout[:maxOutputSize] + "\n... (truncated)"
+	if cwd, err := os.Getwd(); err == nil {
+		if path, err := utils.FindProjectFileWithin(cwd, utils.ProjectBoundary(settings)); err == nil {
+			if data, err := os.ReadFile(path); err == nil {
+				if v := strings.TrimSpace(string(data)); v != "" {
+					return v, path, nil
+				}
+			}
+		}
 	}
-	if len(stderr) > maxOutputSize {
-		stderr = stderr[:maxOutputSize] + "\n... (truncated)"
+
+	data, err := os.ReadFile(utils.JfvmConfig)
+	if err != nil {
+		return "", "", err
 	}
+	return strings.TrimSpace(string(data)), utils.JfvmConfig, nil
+}
 
-	// Add new entry
-	entry := HistoryEntry{
-		Version:   version,
-		Timestamp: time.Now(),
-		Command:   command,
-		Duration:  duration.Milliseconds(),
-		ExitCode:  exitCode,
-		Stdout:    stdout,
-		Stderr:    stderr,
+// ensureVersion resolves spec to an installed version. With auto-install
+// enabled, a missing version is resolved against the release list and
+// installed first, with progress going to stderr.
+func ensureVersion(spec string, settings utils.Settings) (string, error) {
+	if !autoInstallEnabled(settings) {
+		version, err := internal.Resolver{InstalledOnly: true, Settings: &settings}.Resolve(spec)
+		if err != nil {
+			return "", err
+		}
+		if err := utils.CheckVersionExists(version); err != nil {
+			return "", fmt.Errorf("version %s is not installed. Run `jfvm install %s` or set JFVM_AUTO_INSTALL=1", version, version)
+		}
+		return version, nil
+	}
+
+	src, err := internal.ResolveSourceWith(settings, "")
+	if err != nil {
+		return "", err
+	}
+	version, err := internal.Resolver{Source: src, Settings: &settings}.Resolve(spec)
+	if err != nil {
+		return "", err
 	}
-	entries = append(entries, entry)
+	if utils.CheckVersionExists(version) == nil {
+		return version, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "[shim] JFrog CLI %s is not installed, installing...\n", version)
+	err = internal.DownloadAndInstall(version, internal.InstallOptions{Source: src, Output: os.Stderr})
+	if err != nil {
+		return "", fmt.Errorf("auto-install failed: %w", err)
+	}
+	return version, nil
+}
 
-	// Keep only last 1000 entries to prevent unlimited growth
-	if len(entries) > 1000 {
-		entries = entries[len(entries)-1000:]
+func historyDisabled(settings utils.Settings) bool {
+	if v, ok := os.LookupEnv("JFVM_NO_HISTORY"); ok {
+		return utils.IsTruthy(v)
 	}
+	return settings.History.Disabled
+}
 
-	// Save back (silently fail on errors to avoid disrupting normal operation)
-	if data, err := json.MarshalIndent(entries, "", "  "); err == nil {
-		os.MkdirAll(filepath.Dir(historyFile), 0755)
-		os.WriteFile(historyFile, data, 0644)
+func autoInstallEnabled(settings utils.Settings) bool {
+	if v, ok := os.LookupEnv("JFVM_AUTO_INSTALL"); ok {
+		return utils.IsTruthy(v)
 	}
+	return settings.AutoInstall
 }
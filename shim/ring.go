@@ -0,0 +1,57 @@
+package main
+
+// ringBuffer keeps the last len(data) bytes written to it, so capturing a
+// long-running command's output costs constant memory.
+type ringBuffer struct {
+	data    []byte
+	pos     int
+	full    bool
+	written int64
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{data: make([]byte, size)}
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	r.written += int64(n)
+
+	if n >= len(r.data) {
+		copy(r.data, p[n-len(r.data):])
+		r.pos = 0
+		r.full = true
+		return n, nil
+	}
+
+	c := copy(r.data[r.pos:], p)
+	copy(r.data, p[c:])
+	r.pos += n
+	if r.pos >= len(r.data) {
+		r.pos -= len(r.data)
+		r.full = true
+	}
+	return n, nil
+}
+
+// Bytes returns the retained output in write order.
+func (r *ringBuffer) Bytes() []byte {
+	if !r.full {
+		return r.data[:r.pos]
+	}
+	out := make([]byte, 0, len(r.data))
+	out = append(out, r.data[r.pos:]...)
+	return append(out, r.data[:r.pos]...)
+}
+
+// String returns the retained output, marking it when older output was
+// dropped. A nil buffer, used when capture is off, is empty.
+func (r *ringBuffer) String() string {
+	if r == nil {
+		return ""
+	}
+	if r.written > int64(len(r.data)) {
+		return "... (truncated)\n" + string(r.Bytes())
+	}
+	return string(r.Bytes())
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// forwardedSignals are relayed from the shim to the running jf process.
+// They are sent to the shim alone, e.g. by kill or a closing terminal.
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGHUP}
+
+// absorbedSignals are caught but not relayed. Ctrl+C and Ctrl+\ already
+// reach jf through the terminal's foreground process group; relaying them
+// would deliver a second interrupt and cut short jf's graceful shutdown.
+var absorbedSignals = []os.Signal{syscall.SIGINT, syscall.SIGQUIT}
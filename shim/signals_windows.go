@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// forwardedSignals are relayed from the shim to the running jf process.
+var forwardedSignals = []os.Signal{}
+
+// absorbedSignals are caught but not relayed. On Windows the console
+// delivers Ctrl+C to jf directly; catching it keeps the shim alive long
+// enough to record the result.
+var absorbedSignals = []os.Signal{os.Interrupt}